@@ -21,5 +21,5 @@ var startCmd = &cobra.Command{
 
 func init() {
 	startCmd.Flags().StringP("config", "c", "config.yaml", "app config file")
-	startCmd.Flags().StringP("symbol", "s", "", "symbol")
+	startCmd.Flags().StringP("symbols", "s", "", "symbols, separated by commas")
 }
@@ -3,3 +3,5 @@ rpc_config = {
     'port': 9090,
     'token': 'your-rpc-token'
 }
+
+symbol = 'KCS-USDT'
@@ -81,15 +81,39 @@ class RPC(object):
 
         return self.execute(data)
 
-    def get_order_book(self, number):
-        order_book = self.call("GetOrderBook", number=number)
+    def get_order_book(self, symbol, number):
+        order_book = self.call("GetOrderBook", symbol=symbol, number=number)
         if len(order_book['asks']) == 0 or len(order_book['bids']) == 0:
             raise Exception("empty order book")
 
         return order_book
 
-    def add_event_client_id(self, data, channel):
+    def get_aggregated_order_book(self, symbol, number, step):
+        return self.call("GetOrderBook", symbol=symbol, number=number, step=step)
+
+    def get_order_book_range(self, symbol, min_price='', max_price='', size='', bps=0):
+        return self.call("GetOrderBookRange", symbol=symbol, minPrice=min_price, maxPrice=max_price, size=size, bps=bps)
+
+    def add_event_client_id(self, symbol, data, channel):
         args = {}
         for i in data:
             args[i] = [channel]
-        return self.call("AddEventClientOidsToChannels", data=args)
+        return self.call("AddEventClientOidsToChannels", symbol=symbol, data=args)
+
+    def get_queue_position(self, symbol, order_id='', client_oid=''):
+        return self.call("GetQueuePosition", symbol=symbol, orderId=order_id, clientOid=client_oid)
+
+    def get_market_impact(self, symbol, side, size='', funds='', limit_price=''):
+        return self.call("GetMarketImpact", symbol=symbol, side=side, size=size, funds=funds, limitPrice=limit_price)
+
+    def get_book_stats(self, symbol):
+        return self.call("GetBookStats", symbol=symbol)
+
+    def subscribe(self, symbol):
+        return self.call("Subscribe", symbol=symbol)
+
+    def unsubscribe(self, symbol):
+        return self.call("Unsubscribe", symbol=symbol)
+
+    def list_symbols(self):
+        return self.call("ListSymbols")
@@ -1,6 +1,6 @@
 import time
 from level3.rpc import RPC
-from config import rpc_config
+from config import rpc_config, symbol
 from decimal import Decimal
 import os
 import platform
@@ -18,7 +18,7 @@ if __name__ == '__main__':
     rpc = RPC(rpc_config['host'], rpc_config['port'], rpc_config['token'])
 
     while True:
-        order_book = rpc.get_order_book(11)
+        order_book = rpc.get_order_book(symbol, 11)
 #         import sys, json
 #         print(json.dumps(order_book))
 #         sys.exit(0)
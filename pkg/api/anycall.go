@@ -6,6 +6,7 @@ import (
 
 type AnyCallMessage struct {
 	TokenMessage
+	SymbolMessage
 	Method string          `json:"method"`
 	Args   json.RawMessage `json:"args"`
 }
@@ -17,9 +18,12 @@ func (s *Server) AnyCall(message *AnyCallMessage, reply *Response) error {
 	}
 	//log.Debug("AnyCall method: " + message.Method + ", args: " + string(message.Args))
 
-	data, err := s.app.AnyCall(message.Method, message.Args)
+	data, err := s.app.AnyCall(message.Symbol, message.Method, message.Args)
 	if err != nil {
-		*reply = s.failure(ServerErrorCode, err.Error())
+		*reply = s.failureWithError(err)
+		if data != nil {
+			reply.Data = data
+		}
 		return nil
 	}
 
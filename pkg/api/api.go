@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"net"
 	"net/rpc"
 	"net/rpc/jsonrpc"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/Kucoin/kucoin-level3-sdk/pkg/app"
 	"github.com/Kucoin/kucoin-level3-sdk/pkg/cfg"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges"
 	"github.com/Kucoin/kucoin-level3-sdk/pkg/services/log"
 	"go.uber.org/zap"
 )
@@ -61,6 +63,11 @@ type TokenMessage struct {
 	Token string `json:"token"`
 }
 
+//SymbolMessage is symbol type message
+type SymbolMessage struct {
+	Symbol string `json:"symbol"`
+}
+
 //Response is api response
 type Response struct {
 	Code  string      `json:"code"`
@@ -86,10 +93,13 @@ func (s *Server) success(data interface{}) Response {
 }
 
 const (
-	ServerErrorCode = "10"
-	TokenErrorCode  = "20"
-	TickerErrorCode = "30"
-	ConfNotFound    = "40"
+	ServerErrorCode  = "10"
+	TokenErrorCode   = "20"
+	TickerErrorCode  = "30"
+	ConfNotFound     = "40"
+	SymbolNotFound   = "50"
+	NotLiveErrorCode = "60"
+	OrderNotFound    = "70"
 )
 
 func (s *Server) failure(code string, err string) Response {
@@ -99,3 +109,16 @@ func (s *Server) failure(code string, err string) Response {
 		Error: err,
 	}
 }
+
+func (s *Server) failureWithError(err error) Response {
+	switch {
+	case errors.Is(err, exchanges.ErrSymbolNotFound):
+		return s.failure(SymbolNotFound, err.Error())
+	case errors.Is(err, exchanges.ErrOrderBookNotLive):
+		return s.failure(NotLiveErrorCode, err.Error())
+	case errors.Is(err, exchanges.ErrOrderNotFound):
+		return s.failure(OrderNotFound, err.Error())
+	default:
+		return s.failure(ServerErrorCode, err.Error())
+	}
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"fmt"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"testing"
+
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/app"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/cfg"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges"
+)
+
+//testExchange wraps the errors the way the kucoin exchange does, for a live and a non-live symbol
+type testExchange struct {
+	exchanges.BasicExchange
+	live   map[string]bool
+	orders map[string]*exchanges.QueuePosition
+}
+
+func init() {
+	exchanges.RegisterType("api_test", func() (exchanges.Exchange, error) {
+		return &testExchange{
+			live: map[string]bool{"KCS-USDT": true, "BTC-USDT": false},
+			orders: map[string]*exchanges.QueuePosition{
+				"order1": {OrderId: "order1", Side: "buy", Price: "1.5", Size: "2", OrdersAhead: 1, SizeAhead: "3", LevelCount: 3, LevelSize: "6"},
+			},
+		}, nil
+	})
+}
+
+//GetPartOrderBook returns the order book with its state, along with ErrOrderBookNotLive unless it is live
+func (ex *testExchange) GetPartOrderBook(symbol string, number int) (*exchanges.OrderBook, error) {
+	live, ok := ex.live[symbol]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", exchanges.ErrSymbolNotFound, symbol)
+	}
+
+	data := &exchanges.OrderBook{Asks: [][2]string{}, Bids: [][2]string{{"1.5", "6"}}, State: "live", Sequence: 10}
+	if !live {
+		data.State = "resyncing"
+		return data, fmt.Errorf("%w, symbol: %s, state: %s", exchanges.ErrOrderBookNotLive, symbol, data.State)
+	}
+
+	return data, nil
+}
+
+func newTestClient(t *testing.T) *rpc.Client {
+	cfg.AppConfig.Market = map[string]interface{}{"api_test": nil}
+	cfg.AppConfig.ApiServer.Token = "token"
+
+	server := rpc.NewServer()
+	if err := server.Register(&Server{app: app.NewApp()}); err != nil {
+		t.Fatal(err)
+	}
+
+	serverConn, clientConn := net.Pipe()
+	go server.ServeCodec(jsonrpc.NewServerCodec(serverConn))
+	client := jsonrpc.NewClient(clientConn)
+	t.Cleanup(func() { _ = client.Close() })
+
+	return client
+}
+
+//TestGetOrderBookNotLive checks the order book not live is answered with NotLiveErrorCode along with the data
+func TestGetOrderBookNotLive(t *testing.T) {
+	client := newTestClient(t)
+
+	tests := []struct {
+		symbol string
+		code   string
+		state  string
+	}{
+		{"KCS-USDT", "0", "live"},
+		{"BTC-USDT", NotLiveErrorCode, "resyncing"},
+		{"ETH-USDT", SymbolNotFound, ""},
+	}
+	for _, test := range tests {
+		reply := &Response{}
+		message := &GetPartOrderBookMessage{Number: 1, SymbolMessage: SymbolMessage{test.symbol}, TokenMessage: TokenMessage{"token"}}
+		if err := client.Call("Server.GetOrderBook", message, reply); err != nil {
+			t.Fatalf("%s: %v", test.symbol, err)
+		}
+		if reply.Code != test.code {
+			t.Errorf("%s: code %s, want %s, error: %s", test.symbol, reply.Code, test.code, reply.Error)
+		}
+
+		//the data is decoded as a map when it is the order book, and as an empty string otherwise
+		data, ok := reply.Data.(map[string]interface{})
+		if test.state == "" {
+			if ok {
+				t.Errorf("%s: unexpected data %v", test.symbol, reply.Data)
+			}
+			continue
+		}
+		if !ok || data["state"] != test.state || data["sequence"] != float64(10) {
+			t.Errorf("%s: data %v, want the order book of state %s", test.symbol, reply.Data, test.state)
+		}
+	}
+}
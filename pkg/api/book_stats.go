@@ -0,0 +1,25 @@
+package api
+
+type BookStatsMessage struct {
+	SymbolMessage
+	TokenMessage
+}
+
+func (s *Server) GetBookStats(message *BookStatsMessage, reply *Response) error {
+	if errResp := s.checkToken(message.Token); errResp != nil {
+		*reply = *errResp
+		return nil
+	}
+
+	data, err := s.app.GetBookStats(message.Symbol)
+	if err != nil {
+		*reply = s.failureWithError(err)
+		if data != nil {
+			reply.Data = data
+		}
+		return nil
+	}
+
+	*reply = s.success(data)
+	return nil
+}
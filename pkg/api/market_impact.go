@@ -0,0 +1,30 @@
+package api
+
+import (
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges"
+)
+
+type MarketImpactMessage struct {
+	exchanges.MarketOrder
+	SymbolMessage
+	TokenMessage
+}
+
+func (s *Server) GetMarketImpact(message *MarketImpactMessage, reply *Response) error {
+	if errResp := s.checkToken(message.Token); errResp != nil {
+		*reply = *errResp
+		return nil
+	}
+
+	data, err := s.app.GetMarketImpact(message.Symbol, &message.MarketOrder)
+	if err != nil {
+		*reply = s.failureWithError(err)
+		if data != nil {
+			reply.Data = data
+		}
+		return nil
+	}
+
+	*reply = s.success(data)
+	return nil
+}
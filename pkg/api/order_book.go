@@ -1,7 +1,13 @@
 package api
 
+import (
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges"
+)
+
 type GetPartOrderBookMessage struct {
-	Number int `json:"number"`
+	Number int    `json:"number"`
+	Step   string `json:"step"` //aggregate the levels into buckets of the price step, optional
+	SymbolMessage
 	TokenMessage
 }
 
@@ -11,6 +17,47 @@ func (s *Server) GetOrderBook(message *GetPartOrderBookMessage, reply *Response)
 		return nil
 	}
 
-	*reply = s.success(s.app.PartOrderBook(message.Number))
+	var data *exchanges.OrderBook
+	var err error
+	if message.Step != "" {
+		data, err = s.app.AggregatedOrderBook(message.Symbol, message.Number, message.Step)
+	} else {
+		data, err = s.app.PartOrderBook(message.Symbol, message.Number)
+	}
+	if err != nil {
+		*reply = s.failureWithError(err)
+		if data != nil {
+			//the order book with its state, which is not live
+			reply.Data = data
+		}
+		return nil
+	}
+
+	*reply = s.success(data)
+	return nil
+}
+
+type GetOrderBookRangeMessage struct {
+	exchanges.BookRangeQuery
+	SymbolMessage
+	TokenMessage
+}
+
+func (s *Server) GetOrderBookRange(message *GetOrderBookRangeMessage, reply *Response) error {
+	if errResp := s.checkToken(message.Token); errResp != nil {
+		*reply = *errResp
+		return nil
+	}
+
+	data, err := s.app.GetOrderBookRange(message.Symbol, &message.BookRangeQuery)
+	if err != nil {
+		*reply = s.failureWithError(err)
+		if data != nil {
+			reply.Data = data
+		}
+		return nil
+	}
+
+	*reply = s.success(data)
 	return nil
 }
@@ -2,6 +2,7 @@ package api
 
 type AddEventClientOidsMessage struct {
 	Data map[string][]string `json:"data"`
+	SymbolMessage
 	TokenMessage
 }
 
@@ -16,8 +17,8 @@ func (s *Server) AddEventClientOidsToChannels(message *AddEventClientOidsMessage
 		return nil
 	}
 
-	if err := s.app.AddEventClientOidsToChannels(message.Data); err != nil {
-		*reply = s.failure(ServerErrorCode, err.Error())
+	if err := s.app.AddEventClientOidsToChannels(message.Symbol, message.Data); err != nil {
+		*reply = s.failureWithError(err)
 		return nil
 	}
 
@@ -0,0 +1,28 @@
+package api
+
+//QueuePositionMessage looks up an order by orderId, or by a clientOid already mapped by the order watcher
+type QueuePositionMessage struct {
+	OrderId   string `json:"orderId"`
+	ClientOid string `json:"clientOid"`
+	SymbolMessage
+	TokenMessage
+}
+
+func (s *Server) GetQueuePosition(message *QueuePositionMessage, reply *Response) error {
+	if errResp := s.checkToken(message.Token); errResp != nil {
+		*reply = *errResp
+		return nil
+	}
+
+	data, err := s.app.GetQueuePosition(message.Symbol, message.OrderId, message.ClientOid)
+	if err != nil {
+		*reply = s.failureWithError(err)
+		if data != nil {
+			reply.Data = data
+		}
+		return nil
+	}
+
+	*reply = s.success(data)
+	return nil
+}
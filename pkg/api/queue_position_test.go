@@ -0,0 +1,68 @@
+package api
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges"
+)
+
+func (ex *testExchange) GetQueuePosition(symbol string, orderId string, clientOid string) (*exchanges.QueuePosition, error) {
+	live, ok := ex.live[symbol]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", exchanges.ErrSymbolNotFound, symbol)
+	}
+
+	data, ok := ex.orders[orderId]
+	if !ok {
+		return nil, fmt.Errorf("%w, symbol: %s, orderId: %s", exchanges.ErrOrderNotFound, symbol, orderId)
+	}
+	if !live {
+		return data, fmt.Errorf("%w, symbol: %s", exchanges.ErrOrderBookNotLive, symbol)
+	}
+
+	return data, nil
+}
+
+func TestGetQueuePosition(t *testing.T) {
+	client := newTestClient(t)
+
+	tests := []struct {
+		name    string
+		message QueuePositionMessage
+		code    string
+		data    bool
+	}{
+		{"found", QueuePositionMessage{OrderId: "order1", SymbolMessage: SymbolMessage{"KCS-USDT"}}, "0", true},
+		{"token", QueuePositionMessage{OrderId: "order1", SymbolMessage: SymbolMessage{"KCS-USDT"}, TokenMessage: TokenMessage{"wrong"}}, TokenErrorCode, false},
+		{"unknown order", QueuePositionMessage{OrderId: "order2", SymbolMessage: SymbolMessage{"KCS-USDT"}}, OrderNotFound, false},
+		{"unknown symbol", QueuePositionMessage{OrderId: "order1", SymbolMessage: SymbolMessage{"ETH-USDT"}}, SymbolNotFound, false},
+		{"not live", QueuePositionMessage{OrderId: "order1", SymbolMessage: SymbolMessage{"BTC-USDT"}}, NotLiveErrorCode, true},
+	}
+	for _, test := range tests {
+		if test.message.Token == "" {
+			test.message.Token = "token"
+		}
+
+		reply := &Response{}
+		if err := client.Call("Server.GetQueuePosition", &test.message, reply); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if reply.Code != test.code {
+			t.Errorf("%s: code %s, want %s, error: %s", test.name, reply.Code, test.code, reply.Error)
+		}
+		if test.code != "0" && reply.Error == "" {
+			t.Errorf("%s: empty error", test.name)
+		}
+
+		//the data is decoded as a map when it is the queue position, and as an empty string otherwise
+		data, ok := reply.Data.(map[string]interface{})
+		if ok != test.data {
+			t.Errorf("%s: data %v, want the queue position: %v", test.name, reply.Data, test.data)
+			continue
+		}
+		if ok && (data["orderId"] != "order1" || data["ordersAhead"] != float64(1) || data["sizeAhead"] != "3") {
+			t.Errorf("%s: data %v", test.name, data)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package api
+
+type SubscribeSymbolMessage struct {
+	SymbolMessage
+	TokenMessage
+}
+
+func (s *Server) Subscribe(message *SubscribeSymbolMessage, reply *Response) error {
+	if errResp := s.checkToken(message.Token); errResp != nil {
+		*reply = *errResp
+		return nil
+	}
+
+	if message.Symbol == "" {
+		*reply = s.failure(ServerErrorCode, "empty symbol")
+		return nil
+	}
+
+	if err := s.app.Subscribe(message.Symbol); err != nil {
+		*reply = s.failureWithError(err)
+		return nil
+	}
+
+	*reply = s.success("")
+	return nil
+}
+
+func (s *Server) Unsubscribe(message *SubscribeSymbolMessage, reply *Response) error {
+	if errResp := s.checkToken(message.Token); errResp != nil {
+		*reply = *errResp
+		return nil
+	}
+
+	if err := s.app.Unsubscribe(message.Symbol); err != nil {
+		*reply = s.failureWithError(err)
+		return nil
+	}
+
+	*reply = s.success("")
+	return nil
+}
+
+func (s *Server) ListSymbols(message *TokenMessage, reply *Response) error {
+	if errResp := s.checkToken(message.Token); errResp != nil {
+		*reply = *errResp
+		return nil
+	}
+
+	data, err := s.app.ListSymbols()
+	if err != nil {
+		*reply = s.failureWithError(err)
+		return nil
+	}
+
+	*reply = s.success(data)
+	return nil
+}
@@ -26,14 +26,46 @@ func NewApp() *App {
 	return app
 }
 
-func (app *App) PartOrderBook(number int) *exchanges.OrderBook {
-	return app.exchange.GetPartOrderBook(number)
+func (app *App) PartOrderBook(symbol string, number int) (*exchanges.OrderBook, error) {
+	return app.exchange.GetPartOrderBook(symbol, number)
 }
 
-func (app *App) AddEventClientOidsToChannels(data map[string][]string) error {
-	return app.exchange.AddEventClientOidsToChannels(data)
+func (app *App) AggregatedOrderBook(symbol string, number int, step string) (*exchanges.OrderBook, error) {
+	return app.exchange.GetAggregatedOrderBook(symbol, number, step)
 }
 
-func (app *App) AnyCall(method string, args json.RawMessage) (interface{}, error) {
-	return app.exchange.AnyCall(method, args)
+func (app *App) AddEventClientOidsToChannels(symbol string, data map[string][]string) error {
+	return app.exchange.AddEventClientOidsToChannels(symbol, data)
+}
+
+func (app *App) AnyCall(symbol string, method string, args json.RawMessage) (interface{}, error) {
+	return app.exchange.AnyCall(symbol, method, args)
+}
+
+func (app *App) Subscribe(symbol string) error {
+	return app.exchange.Subscribe(symbol)
+}
+
+func (app *App) Unsubscribe(symbol string) error {
+	return app.exchange.Unsubscribe(symbol)
+}
+
+func (app *App) ListSymbols() ([]*exchanges.SymbolStatus, error) {
+	return app.exchange.ListSymbols()
+}
+
+func (app *App) GetOrderBookRange(symbol string, query *exchanges.BookRangeQuery) (*exchanges.OrderBook, error) {
+	return app.exchange.GetOrderBookRange(symbol, query)
+}
+
+func (app *App) GetBookStats(symbol string) (*exchanges.BookStats, error) {
+	return app.exchange.GetBookStats(symbol)
+}
+
+func (app *App) GetMarketImpact(symbol string, order *exchanges.MarketOrder) (*exchanges.MarketImpact, error) {
+	return app.exchange.GetMarketImpact(symbol, order)
+}
+
+func (app *App) GetQueuePosition(symbol string, orderId string, clientOid string) (*exchanges.QueuePosition, error) {
+	return app.exchange.GetQueuePosition(symbol, orderId, clientOid)
 }
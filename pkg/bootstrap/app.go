@@ -22,7 +22,7 @@ import (
 func Run(cfgFile string, flagSet *pflag.FlagSet) {
 	//load cfg
 	configFile, err := cfg.LoadConfig(cfgFile, flagSet, map[string]string{
-		"symbol": "symbol",
+		"symbols": "symbols",
 	})
 	if err != nil {
 		panic(err)
@@ -52,7 +52,7 @@ func Run(cfgFile string, flagSet *pflag.FlagSet) {
 	fmt.Println("market finished bootstrap")
 	// Wait for interrupt signal to gracefully shutdown the server with
 	// a timeout of 5 seconds.
-	quit := make(chan os.Signal)
+	quit := make(chan os.Signal, 1)
 	// kill (no param) default send syscall.SIGTERM
 	// kill -2 is syscall.SIGINT
 	// kill -9 is syscall.SIGKILL but can't be catch, so don't need add it
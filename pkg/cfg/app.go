@@ -7,7 +7,7 @@ import (
 type appConf struct {
 	AppDebug bool `mapstructure:"app_debug"`
 
-	Symbol string `mapstructure:"symbol" validate:"required"`
+	Symbols []string `mapstructure:"symbols" validate:"required,min=1,dive,required"`
 
 	App App `mapstructure:"app" validate:"required,dive"`
 
@@ -8,37 +8,199 @@ import (
 	"go.uber.org/zap"
 )
 
+var (
+	ErrSymbolNotFound   = errors.New("symbol not found")
+	ErrOrderBookNotLive = errors.New("order book is not live")
+	ErrOrderNotFound    = errors.New("order not found")
+)
+
 type Exchange interface {
-	GetPartOrderBook(number int) *OrderBook
-	AddEventClientOidsToChannels(data map[string][]string) error
-	AnyCall(method string, args json.RawMessage) (interface{}, error)
+	GetPartOrderBook(symbol string, number int) (*OrderBook, error)
+	GetAggregatedOrderBook(symbol string, number int, step string) (*OrderBook, error)
+	AddEventClientOidsToChannels(symbol string, data map[string][]string) error
+	AnyCall(symbol string, method string, args json.RawMessage) (interface{}, error)
+	Subscribe(symbol string) error
+	Unsubscribe(symbol string) error
+	ListSymbols() ([]*SymbolStatus, error)
+	GetQueuePosition(symbol string, orderId string, clientOid string) (*QueuePosition, error)
+	GetMarketImpact(symbol string, order *MarketOrder) (*MarketImpact, error)
+	GetBookStats(symbol string) (*BookStats, error)
+	GetOrderBookRange(symbol string, query *BookRangeQuery) (*OrderBook, error)
+}
+
+type SymbolStatus struct {
+	Symbol    string      `json:"symbol"`
+	State     string      `json:"state"`
+	Sequence  uint64      `json:"sequence"`
+	SyncStats interface{} `json:"syncStats,omitempty"`
+
+	//SizeUnit is the unit of all the sizes of the symbol, "base" of spot, "lot" of futures,
+	//a lot is a contract of Multiplier units of the base currency, or -Multiplier of the quote currency of an inverse contract
+	SizeUnit   string `json:"sizeUnit,omitempty"`
+	Multiplier string `json:"multiplier,omitempty"`
+
+	Consumers interface{} `json:"consumers,omitempty"`
+
+	LastMessageTime int64 `json:"lastMessageTime"`
 }
 
 type OrderBook struct {
-	Asks interface{} `json:"asks"`
-	Bids interface{} `json:"bids"`
-	Time string      `json:"time"`
-	Info interface{} `json:"info,omitempty"`
+	Asks           interface{} `json:"asks"`
+	Bids           interface{} `json:"bids"`
+	Time           string      `json:"time"`
+	State          string      `json:"state"`
+	Sequence       uint64      `json:"sequence"`
+	LastUpdateTime uint64      `json:"lastUpdateTime"`
+	Info           interface{} `json:"info,omitempty"`
 }
 
 type Level3OrderBook struct {
-	Asks [][3]string `json:"asks"`
-	Bids [][3]string `json:"bids"`
-	Info interface{} `json:"info,omitempty"`
+	Asks           [][3]string `json:"asks"`
+	Bids           [][3]string `json:"bids"`
+	State          string      `json:"state"`
+	Sequence       uint64      `json:"sequence"`
+	LastUpdateTime uint64      `json:"lastUpdateTime"`
+	Info           interface{} `json:"info,omitempty"`
+}
+
+//BookRangeQuery selects the price levels of both sides by a price range, by a cumulative size,
+//or by a distance from the mid price
+type BookRangeQuery struct {
+	MinPrice string `json:"minPrice"` //the levels priced between MinPrice and MaxPrice inclusive, either may be empty
+	MaxPrice string `json:"maxPrice"`
+	Size     string `json:"size"` //the levels from the best price until their cumulative size covers Size
+	Bps      int    `json:"bps"`  //the levels within Bps of the mid price
+}
+
+//Level3ExtendedOrderBook is the level3 order book with the times and fills of the orders
+type Level3ExtendedOrderBook struct {
+	Asks           []*Level3Order `json:"asks"`
+	Bids           []*Level3Order `json:"bids"`
+	State          string         `json:"state"`
+	Sequence       uint64         `json:"sequence"`
+	LastUpdateTime uint64         `json:"lastUpdateTime"`
+}
+
+type Level3Order struct {
+	OrderId    string `json:"orderId"`
+	Price      string `json:"price"`
+	Size       string `json:"size"`
+	Time       uint64 `json:"time"`       //unix nano, when the order opened
+	UpdateTime uint64 `json:"updateTime"` //unix nano, when the size changed last, the open time if never
+	Fills      int    `json:"fills"`      //partial fills seen since the order book was built
+	Age        uint64 `json:"age"`        //nanoseconds from the open time to the latest message
+}
+
+//QueuePosition is the position of a resting order in the FIFO queue of its price level
+type QueuePosition struct {
+	OrderId     string `json:"orderId"`
+	ClientOid   string `json:"clientOid,omitempty"`
+	Side        string `json:"side"`
+	Price       string `json:"price"`
+	Size        string `json:"size"`
+	OrdersAhead int    `json:"ordersAhead"`
+	SizeAhead   string `json:"sizeAhead"`
+	LevelCount  int    `json:"levelCount"`
+	LevelSize   string `json:"levelSize"`
+	State       string `json:"state"`
+	Sequence    uint64 `json:"sequence"`
+}
+
+//MarketOrder is a hypothetical market order of Size or of Funds, with an optional LimitPrice
+type MarketOrder struct {
+	Side       string `json:"side"` //buy or sell
+	Size       string `json:"size"`
+	Funds      string `json:"funds"`
+	LimitPrice string `json:"limitPrice"`
+}
+
+//MarketImpact is the simulated fill of a MarketOrder on the order book
+type MarketImpact struct {
+	Side         string `json:"side"`
+	FilledSize   string `json:"filledSize"`
+	FilledFunds  string `json:"filledFunds"`
+	AveragePrice string `json:"averagePrice"`
+	WorstPrice   string `json:"worstPrice"`
+	Levels       int    `json:"levels"`
+	MidPrice     string `json:"midPrice"`
+	Slippage     string `json:"slippage"` //how much the average price is worse than the mid price
+	SlippageBps  string `json:"slippageBps"`
+	Complete     bool   `json:"complete"` //the whole size or funds is filled within the limit price
+	State        string `json:"state"`
+	Sequence     uint64 `json:"sequence"`
+}
+
+//BookStats are the market statistics of the order book
+type BookStats struct {
+	BestAsk     string       `json:"bestAsk"`
+	BestAskSize string       `json:"bestAskSize"`
+	BestBid     string       `json:"bestBid"`
+	BestBidSize string       `json:"bestBidSize"`
+	Spread      string       `json:"spread"`
+	SpreadTicks int64        `json:"spreadTicks"`
+	SpreadBps   string       `json:"spreadBps"`
+	Mid         string       `json:"mid"`
+	Microprice  string       `json:"microprice"` //the mid weighted by the best size of the other side
+	Depth       int          `json:"depth"`
+	AskVolume   string       `json:"askVolume"` //size of the best Depth levels
+	BidVolume   string       `json:"bidVolume"`
+	Imbalance   string       `json:"imbalance"` //(bidVolume - askVolume) / (bidVolume + askVolume)
+	Bands       []*DepthBand `json:"bands"`
+	State       string       `json:"state"`
+	Sequence    uint64       `json:"sequence"`
+}
+
+//DepthBand is the cumulative size within Bps of the mid price
+type DepthBand struct {
+	Bps     int    `json:"bps"`
+	AskSize string `json:"askSize"`
+	BidSize string `json:"bidSize"`
 }
 
 type BasicExchange struct {
 }
 
-func (be *BasicExchange) GetPartOrderBook(number int) *OrderBook {
-	return nil
+func (be *BasicExchange) GetPartOrderBook(symbol string, number int) (*OrderBook, error) {
+	return nil, errors.New("unsupported rpc method: GetPartOrderBook")
+}
+
+func (be *BasicExchange) GetAggregatedOrderBook(symbol string, number int, step string) (*OrderBook, error) {
+	return nil, errors.New("unsupported rpc method: GetAggregatedOrderBook")
 }
 
-func (be *BasicExchange) AddEventClientOidsToChannels(data map[string][]string) error {
+func (be *BasicExchange) AddEventClientOidsToChannels(symbol string, data map[string][]string) error {
 	return errors.New("unsupported rpc method: AddEventClientOidsToChannels")
 }
 
-func (be *BasicExchange) AnyCall(method string, args json.RawMessage) (ret interface{}, err error) {
+func (be *BasicExchange) Subscribe(symbol string) error {
+	return errors.New("unsupported rpc method: Subscribe")
+}
+
+func (be *BasicExchange) Unsubscribe(symbol string) error {
+	return errors.New("unsupported rpc method: Unsubscribe")
+}
+
+func (be *BasicExchange) ListSymbols() ([]*SymbolStatus, error) {
+	return nil, errors.New("unsupported rpc method: ListSymbols")
+}
+
+func (be *BasicExchange) GetQueuePosition(symbol string, orderId string, clientOid string) (*QueuePosition, error) {
+	return nil, errors.New("unsupported rpc method: GetQueuePosition")
+}
+
+func (be *BasicExchange) GetMarketImpact(symbol string, order *MarketOrder) (*MarketImpact, error) {
+	return nil, errors.New("unsupported rpc method: GetMarketImpact")
+}
+
+func (be *BasicExchange) GetBookStats(symbol string) (*BookStats, error) {
+	return nil, errors.New("unsupported rpc method: GetBookStats")
+}
+
+func (be *BasicExchange) GetOrderBookRange(symbol string, query *BookRangeQuery) (*OrderBook, error) {
+	return nil, errors.New("unsupported rpc method: GetOrderBookRange")
+}
+
+func (be *BasicExchange) AnyCall(symbol string, method string, args json.RawMessage) (ret interface{}, err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			log.Error("AnyCall panic", zap.Any("r", r))
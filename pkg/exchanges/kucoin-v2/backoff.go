@@ -0,0 +1,37 @@
+package kucoin_v2
+
+import (
+	"math/rand"
+	"time"
+)
+
+//backoff computes exponential reconnect delays with jitter
+type backoff struct {
+	min     time.Duration
+	max     time.Duration
+	attempt uint
+}
+
+func newBackoff(min, max time.Duration) *backoff {
+	return &backoff{
+		min: min,
+		max: max,
+	}
+}
+
+//next returns a random delay in [d/2, d], d doubles on each attempt until max
+func (b *backoff) next() time.Duration {
+	d := b.min << b.attempt
+	if d <= 0 || d >= b.max {
+		d = b.max
+	} else {
+		b.attempt++
+	}
+
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(d-half)+1))
+}
+
+func (b *backoff) reset() {
+	b.attempt = 0
+}
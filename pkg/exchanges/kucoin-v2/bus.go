@@ -0,0 +1,237 @@
+package kucoin_v2
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/sdk"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/stream"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/services/log"
+	"go.uber.org/zap"
+)
+
+//policy is what the bus does when the channel of a consumer is full
+type policy string
+
+const (
+	//policyBlock waits for the consumer, which stalls the other consumers of the market
+	policyBlock policy = "block"
+	//policyDropAndResync drops the events until the consumer catches up, and asks it to resync once
+	policyDropAndResync policy = "drop_and_resync"
+	//policyDropOldest drops the oldest queued event to make room for the new one
+	policyDropOldest policy = "drop_oldest"
+	//policySpill queues the events on disk until the consumer catches up, none is lost
+	policySpill policy = "spill"
+)
+
+//consumer receives the events of a market on its own channel
+type consumer struct {
+	name    string
+	events  chan *stream.Event
+	policy  policy
+	warnLen int
+	resync  func()      //of policyDropAndResync, nil if the consumer has nothing to resync
+	spill   *spillQueue //of policySpill
+
+	resyncing bool //a resync was requested since the last delivered event
+	above     bool //more than warnLen events were queued at the last check
+
+	highWater int64  //the most events queued in the channel
+	dropped   uint64 //events lost by policyDropAndResync and policyDropOldest
+	spilled   uint64 //events queued on disk by policySpill
+}
+
+//ConsumerStats reports the channel of a consumer
+type ConsumerStats struct {
+	Name      string `json:"name"`
+	Policy    string `json:"policy"`
+	Len       int    `json:"len"`
+	Cap       int    `json:"cap"`
+	HighWater int64  `json:"highWater"`
+	Dropped   uint64 `json:"dropped"`
+	Spilled   uint64 `json:"spilled"`
+	Pending   int    `json:"pending"` //events on disk
+}
+
+func (c *consumer) stats() ConsumerStats {
+	stats := ConsumerStats{
+		Name:      c.name,
+		Policy:    string(c.policy),
+		Len:       len(c.events),
+		Cap:       cap(c.events),
+		HighWater: atomic.LoadInt64(&c.highWater),
+		Dropped:   atomic.LoadUint64(&c.dropped),
+		Spilled:   atomic.LoadUint64(&c.spilled),
+	}
+	if c.spill != nil {
+		stats.Pending = c.spill.len()
+	}
+
+	return stats
+}
+
+//decoder decodes the raw messages of a bus, also when the spilled events are read back
+type decoder func(msg *sdk.WebSocketDownstreamMessage) (*stream.Event, error)
+
+//streamDecoder decodes the level3 messages of the spot or the futures stream
+func streamDecoder(future bool) decoder {
+	return func(msg *sdk.WebSocketDownstreamMessage) (*stream.Event, error) {
+		return stream.NewEvent(msg, future)
+	}
+}
+
+//eventBus fans the events of a market out to the consumers, in the order of subscription,
+//the caller serializes publish
+type eventBus struct {
+	symbol   string
+	decode   decoder
+	spillDir string
+
+	lock      *sync.RWMutex //the stats are read while a blocking consumer stalls publish
+	consumers []*consumer
+	done      chan struct{} //closed by close, so the publish blocked on a full channel returns
+}
+
+func newEventBus(symbol string, decode decoder, spillDir string) *eventBus {
+	return &eventBus{
+		symbol:   symbol,
+		decode:   decode,
+		spillDir: spillDir,
+		lock:     &sync.RWMutex{},
+		done:     make(chan struct{}),
+	}
+}
+
+func (b *eventBus) subscribe(name string, events chan *stream.Event, config ConsumerConfig, resync func()) *consumer {
+	c := &consumer{
+		name:    name,
+		events:  events,
+		policy:  policy(config.Policy),
+		warnLen: config.WarnLen,
+		resync:  resync,
+	}
+	if c.policy == policySpill {
+		c.spill = newSpillQueue(b.symbol, name, b.spillDir, events, b.decode)
+	}
+
+	b.lock.Lock()
+	b.consumers = append(b.consumers, c)
+	b.lock.Unlock()
+	return c
+}
+
+func (b *eventBus) publish(event *stream.Event) {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	for _, c := range b.consumers {
+		b.send(c, event)
+
+		if n := int64(len(c.events)); n > atomic.LoadInt64(&c.highWater) {
+			atomic.StoreInt64(&c.highWater, n)
+		}
+	}
+}
+
+func (b *eventBus) send(c *consumer, event *stream.Event) {
+	switch c.policy {
+	case policyDropAndResync:
+		select {
+		case c.events <- event:
+			c.resyncing = false
+		default:
+			atomic.AddUint64(&c.dropped, 1)
+			if !c.resyncing {
+				c.resyncing = true
+				log.Warn("consumer is too slow, drop events", zap.String("symbol", b.symbol), zap.String("consumer", c.name))
+				if c.resync != nil {
+					c.resync()
+				}
+			}
+		}
+
+	case policyDropOldest:
+		for {
+			select {
+			case c.events <- event:
+				return
+			default:
+			}
+
+			select {
+			case <-c.events:
+				atomic.AddUint64(&c.dropped, 1)
+			default:
+			}
+		}
+
+	case policySpill:
+		//once spilling, the events follow the spilled ones on disk to keep the order
+		if c.spill.len() == 0 {
+			select {
+			case c.events <- event:
+				return
+			default:
+			}
+		}
+
+		c.spill.push(event)
+		atomic.AddUint64(&c.spilled, 1)
+
+	default:
+		select {
+		case c.events <- event:
+		case <-b.done:
+		}
+	}
+}
+
+//check warns when a consumer falls behind, once until it catches up, it is called by a single goroutine
+func (b *eventBus) check() {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	for _, c := range b.consumers {
+		n := len(c.events)
+		if c.spill != nil {
+			n += c.spill.len()
+		}
+
+		switch {
+		case n > c.warnLen && !c.above:
+			c.above = true
+			log.Warn("consumer falls behind", zap.String("symbol", b.symbol), zap.Any("stats", c.stats()))
+		case n <= c.warnLen && c.above:
+			c.above = false
+			log.Info("consumer catches up", zap.String("symbol", b.symbol), zap.Any("stats", c.stats()))
+		}
+	}
+}
+
+func (b *eventBus) stats() []ConsumerStats {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	stats := make([]ConsumerStats, 0, len(b.consumers))
+	for _, c := range b.consumers {
+		stats = append(stats, c.stats())
+	}
+
+	return stats
+}
+
+//close closes the channels of the consumers, so they quit, the events published afterwards are dropped
+func (b *eventBus) close() {
+	close(b.done)
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	for _, c := range b.consumers {
+		if c.spill != nil {
+			c.spill.close()
+		}
+		close(c.events)
+	}
+	b.consumers = nil
+}
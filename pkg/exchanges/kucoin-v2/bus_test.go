@@ -0,0 +1,124 @@
+package kucoin_v2
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/sdk"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/stream"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/services/log"
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	_ = log.SetLogger(zap.NewNop())
+	defaultConfig.Type = "spot"
+	os.Exit(m.Run())
+}
+
+func newTestEvent(t *testing.T, sequence uint64) *stream.Event {
+	raw, err := json.Marshal(map[string]interface{}{"sequence": sequence, "orderId": "a", "ts": sequence})
+	if err != nil {
+		t.Fatal(err)
+	}
+	event, err := stream.NewEvent(&sdk.WebSocketDownstreamMessage{Subject: stream.MessageDoneType, RawData: raw}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+func receiveSequences(c *consumer, n int) []uint64 {
+	var sequences []uint64
+	for len(sequences) < n {
+		select {
+		case event, ok := <-c.events:
+			if !ok {
+				return sequences
+			}
+			sequences = append(sequences, event.Sequence)
+		case <-time.After(time.Second):
+			return sequences
+		}
+	}
+	return sequences
+}
+
+func TestEventBusPolicies(t *testing.T) {
+	bus := newEventBus("KCS-USDT", streamDecoder(false), t.TempDir())
+	block := bus.subscribe("block", make(chan *stream.Event, 10), ConsumerConfig{Policy: string(policyBlock)}, nil)
+	resyncs := 0
+	resync := bus.subscribe("resync", make(chan *stream.Event, 2), ConsumerConfig{Policy: string(policyDropAndResync)}, func() { resyncs++ })
+	oldest := bus.subscribe("oldest", make(chan *stream.Event, 2), ConsumerConfig{Policy: string(policyDropOldest)}, nil)
+
+	for sequence := uint64(1); sequence <= 5; sequence++ {
+		bus.publish(newTestEvent(t, sequence))
+	}
+
+	if got := block.stats(); got.Len != 5 || got.HighWater != 5 || got.Dropped != 0 {
+		t.Errorf("the blocking consumer should receive every event: %+v", got)
+	}
+	if got := resync.stats(); got.Len != 2 || got.Dropped != 3 || resyncs != 1 {
+		t.Errorf("the slow consumer should drop the new events and resync once: %+v, resyncs %d", got, resyncs)
+	}
+	if got := receiveSequences(oldest, 2); oldest.dropped != 3 || len(got) != 2 || got[0] != 4 || got[1] != 5 {
+		t.Errorf("the slow consumer should keep the newest events, got %v, dropped %d", got, oldest.dropped)
+	}
+
+	//a resync is requested again once the consumer caught up and falls behind again
+	receiveSequences(resync, 2)
+	for sequence := uint64(6); sequence <= 9; sequence++ {
+		bus.publish(newTestEvent(t, sequence))
+	}
+	if resyncs != 2 {
+		t.Errorf("unexpected resyncs: %d", resyncs)
+	}
+
+	bus.close()
+	//close closes the channels of the consumers, the queued events are still received
+	if got := len(receiveSequences(oldest, 10)); got != 2 {
+		t.Errorf("unexpected queued events after close: %d", got)
+	}
+	if _, ok := <-oldest.events; ok {
+		t.Errorf("close should close the channels of the consumers")
+	}
+}
+
+func TestEventBusSpill(t *testing.T) {
+	dir := t.TempDir()
+	bus := newEventBus("KCS-USDT", streamDecoder(false), dir)
+	spill := bus.subscribe("spill", make(chan *stream.Event, 2), ConsumerConfig{Policy: string(policySpill)}, nil)
+
+	for sequence := uint64(1); sequence <= 10; sequence++ {
+		bus.publish(newTestEvent(t, sequence))
+	}
+	if got := spill.stats(); got.Spilled == 0 || got.Dropped != 0 {
+		t.Errorf("the slow consumer should spill the events: %+v", got)
+	}
+
+	got := receiveSequences(spill, 10)
+	for i, sequence := range got {
+		if sequence != uint64(i+1) {
+			t.Fatalf("the spilled events should be fed back in order, got %v", got)
+		}
+	}
+	if len(got) != 10 {
+		t.Fatalf("the spilled events should be fed back, got %v", got)
+	}
+
+	//the spill file is removed once fed back, then the channel is used directly again
+	for spill.spill.len() > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := os.Stat(spill.spill.path); !os.IsNotExist(err) {
+		t.Errorf("the spill file should be removed once fed back: %v", err)
+	}
+	bus.publish(newTestEvent(t, 11))
+	if got := spill.stats(); got.Len != 1 || got.Pending != 0 {
+		t.Errorf("unexpected stats: %+v", got)
+	}
+
+	bus.close()
+}
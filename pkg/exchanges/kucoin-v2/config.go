@@ -1,5 +1,11 @@
 package kucoin_v2
 
+import (
+	"time"
+
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/consts"
+)
+
 type Config struct {
 	URL  string `mapstructure:"url" validate:"required"`
 	Type string `mapstructure:"type" validate:"required"`
@@ -8,6 +14,88 @@ type Config struct {
 	Key        string `mapstructure:"key" validate:"required"`
 	Secret     string `mapstructure:"secret" validate:"required"`
 	Passphrase string `mapstructure:"passphrase" validate:"required"`
+
+	//redundant websocket connections subscribing the same topics, the first arrival of each sequence is used
+	Connections int `mapstructure:"connections" validate:"min=1"`
+
+	//subscribe the order changes of our own account on a private connection for the OrderWatcher
+	PrivateOrders bool `mapstructure:"private_orders"`
+
+	ReconnectMinDelay time.Duration `mapstructure:"reconnect_min_delay" validate:"gt=0"`
+	ReconnectMaxDelay time.Duration `mapstructure:"reconnect_max_delay" validate:"gtefield=ReconnectMinDelay"`
+
+	//mark the order book stale when no message is received for StaleTimeout,
+	//and reconnect a connection receiving nothing, not even pongs, for ReconnectTimeout, 0 to disable
+	StaleTimeout     time.Duration `mapstructure:"stale_timeout" validate:"gte=0"`
+	ReconnectTimeout time.Duration `mapstructure:"reconnect_timeout" validate:"gte=0"`
+
+	//hold messages arriving before their previous sequence, 0 to resync on any sequence gap
+	ReorderWindow  int           `mapstructure:"reorder_window" validate:"gte=0"`
+	ReorderTimeout time.Duration `mapstructure:"reorder_timeout" validate:"gte=0"`
+
+	//the volume imbalance of GetBookStats is of the best StatsDepth levels,
+	//and the cumulative depth is within each of StatsBands bps of the mid price
+	StatsDepth int   `mapstructure:"stats_depth" validate:"gte=0"`
+	StatsBands []int `mapstructure:"stats_bands" validate:"dive,gt=0,lt=10000"`
+
+	//the best ViewDepth levels and orders are kept for the readers after every message,
+	//GetOrderBook of more levels reads the published view of the full order book
+	ViewDepth int `mapstructure:"view_depth" validate:"gt=0"`
+
+	//the channel of each consumer of the stream of a symbol, orderbook or order_watcher,
+	//and what is done when the consumer falls behind, the spill policy queues the events in SpillDir
+	Consumers map[string]ConsumerConfig `mapstructure:"consumers" validate:"dive,keys,oneof=orderbook order_watcher private_orders,endkeys"`
+	SpillDir  string                    `mapstructure:"spill_dir" validate:"required"`
+
+	//a checkpoint of each live order book is written to CheckpointDir every CheckpointInterval,
+	//on start the order book is loaded from it if the stream continues its sequence, instead of a snapshot, 0 to disable
+	CheckpointDir      string        `mapstructure:"checkpoint_dir" validate:"required_with=CheckpointInterval"`
+	CheckpointInterval time.Duration `mapstructure:"checkpoint_interval" validate:"gte=0"`
+}
+
+type ConsumerConfig struct {
+	Policy  string `mapstructure:"policy" validate:"omitempty,oneof=block drop_and_resync drop_oldest spill"`
+	ChanLen int    `mapstructure:"chan_len" validate:"gte=0"`
+	//warn when more events are queued, half of ChanLen if 0
+	WarnLen int `mapstructure:"warn_len" validate:"gte=0"`
+}
+
+var defaultConfig = Config{
+	Connections:        1,
+	ReconnectMinDelay:  time.Second,
+	ReconnectMaxDelay:  time.Minute,
+	StaleTimeout:       30 * time.Second,
+	ReconnectTimeout:   2 * time.Minute,
+	ReorderWindow:      100,
+	ReorderTimeout:     3 * time.Second,
+	StatsDepth:         10,
+	StatsBands:         []int{10, 50, 100},
+	ViewDepth:          50,
+	SpillDir:           "./runtime/spill",
+	CheckpointDir:      "./runtime/checkpoint",
+	CheckpointInterval: time.Minute,
 }
 
-var defaultConfig = Config{}
+//defaultConsumers fills the fields missing in Consumers,
+//a slow order watcher, e.g. blocked on redis, must not stall the order book
+var defaultConsumers = map[string]ConsumerConfig{
+	"orderbook":     {Policy: string(policyBlock), ChanLen: consts.MaxMsgChanLen},
+	"order_watcher": {Policy: string(policySpill), ChanLen: consts.MaxMsgChanLen},
+	//the private order changes of PrivateOrders
+	"private_orders": {Policy: string(policySpill), ChanLen: consts.MaxMsgChanLen},
+}
+
+func consumerConfig(name string) ConsumerConfig {
+	config := defaultConfig.Consumers[name]
+	if config.Policy == "" {
+		config.Policy = defaultConsumers[name].Policy
+	}
+	if config.ChanLen == 0 {
+		config.ChanLen = defaultConsumers[name].ChanLen
+	}
+	if config.WarnLen == 0 {
+		config.WarnLen = config.ChanLen / 2
+	}
+
+	return config
+}
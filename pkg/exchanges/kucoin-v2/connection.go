@@ -0,0 +1,214 @@
+package kucoin_v2
+
+import (
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/sdk"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/services/log"
+	"go.uber.org/zap"
+)
+
+//wsClient is the websocket client of a connection, *sdk.WebSocketClient or a fake in tests
+type wsClient interface {
+	Connect() (<-chan *sdk.WebSocketDownstreamMessage, <-chan error, error)
+	Subscribe(channels ...*sdk.WebSocketSubscribeMessage) error
+	Unsubscribe(channels ...*sdk.WebSocketUnsubscribeMessage) error
+	Stop()
+	LastReceiveTime() int64
+}
+
+//liveClient holds the live client of a connection for the watchdog, client is nil when disconnected
+type liveClient struct {
+	client wsClient
+}
+
+//connection is one of the redundant websocket connections subscribing the topics of all markets
+type connection struct {
+	id int
+	ex *Exchange
+
+	client        wsClient      //guarded by ex.lock
+	connectedTime int64         //unix nano, 0 when disconnected
+	reconnect     chan struct{} //force the websocket to reconnect
+	live          atomic.Value  //liveClient, the client without ex.lock
+
+	newClient func() (wsClient, error) //a client of a new public token
+	sleep     func(d time.Duration)    //waits for the reconnect delay
+
+	messages   uint64
+	reconnects uint64
+}
+
+func newConnection(id int, ex *Exchange) *connection {
+	return &connection{
+		id:        id,
+		ex:        ex,
+		reconnect: make(chan struct{}, 1),
+
+		newClient: func() (wsClient, error) {
+			tk, err := ex.apiService.WebSocketPublicToken()
+			if err != nil {
+				return nil, errors.New("WebSocketPublicToken err: " + err.Error())
+			}
+
+			return ex.apiService.NewWebSocketClient(tk), nil
+		},
+		sleep: time.Sleep,
+	}
+}
+
+//ConnectionStats reports a connection and the messages it delivered first for a symbol
+type ConnectionStats struct {
+	Id            int    `json:"id"`
+	Connected     bool   `json:"connected"`
+	ConnectedTime int64  `json:"connectedTime"`
+	Reconnects    uint64 `json:"reconnects"`
+	Messages      uint64 `json:"messages"`   //messages of all symbols
+	Firsts        uint64 `json:"firsts"`     //messages of the symbol delivered first
+	Duplicates    uint64 `json:"duplicates"` //messages of the symbol delivered by another connection first
+}
+
+func (c *connection) stats(m *market) *ConnectionStats {
+	connectedTime := atomic.LoadInt64(&c.connectedTime)
+	return &ConnectionStats{
+		Id:            c.id,
+		Connected:     connectedTime != 0,
+		ConnectedTime: connectedTime,
+		Reconnects:    atomic.LoadUint64(&c.reconnects),
+		Messages:      atomic.LoadUint64(&c.messages),
+		Firsts:        atomic.LoadUint64(&m.firsts[c.id]),
+		Duplicates:    atomic.LoadUint64(&m.duplicates[c.id]),
+	}
+}
+
+func (c *connection) connected() bool {
+	return atomic.LoadInt64(&c.connectedTime) != 0
+}
+
+//currentClient returns the live client without ex.lock, nil when disconnected
+func (c *connection) currentClient() wsClient {
+	live, _ := c.live.Load().(liveClient)
+	return live.client
+}
+
+//lastReceiveTime returns the unix nano time the live client last received anything, 0 when disconnected
+func (c *connection) lastReceiveTime() int64 {
+	client := c.currentClient()
+	if client == nil {
+		return 0
+	}
+
+	return client.LastReceiveTime()
+}
+
+func (c *connection) forceReconnect() {
+	select {
+	case c.reconnect <- struct{}{}:
+	default:
+	}
+}
+
+//run keeps the websocket connection alive, it reconnects with backoff,
+//and resyncs all order books when no other connection is alive
+func (c *connection) run() {
+	logger := log.Logger().With(zap.Int("connection", c.id))
+	retry := newBackoff(defaultConfig.ReconnectMinDelay, defaultConfig.ReconnectMaxDelay)
+	for {
+		err := c.connect(retry)
+		logger.Error("websocket disconnected", zap.Error(err))
+		atomic.AddUint64(&c.reconnects, 1)
+
+		if !c.ex.anyConnected() {
+			for _, m := range c.ex.markets.all() {
+				m.ob.Resync()
+			}
+		}
+
+		delay := retry.next()
+		logger.Info("websocket reconnect in " + delay.String())
+		c.sleep(delay)
+	}
+}
+
+//connect connects the websocket server and subscribes all topics,
+//then dispatches messages until the connection fails
+func (c *connection) connect(retry *backoff) error {
+	client, err := c.newClient()
+	if err != nil {
+		return err
+	}
+
+	mc, ec, err := client.Connect()
+	if err != nil {
+		return errors.New("Connect err: " + err.Error())
+	}
+
+	if err := c.subscribeAll(client); err != nil {
+		client.Stop()
+		return errors.New("Subscribe err: " + err.Error())
+	}
+	retry.reset()
+
+	//drop the reconnect request of the previous connection
+	select {
+	case <-c.reconnect:
+	default:
+	}
+
+	for {
+		select {
+		case <-c.reconnect:
+			c.resetClient()
+			client.Stop()
+			return errors.New("force reconnect, the feed is silent")
+
+		case err := <-ec:
+			c.resetClient()
+			client.Stop() // Stop subscribing the WebSocket feed
+			return err
+
+		case msg, ok := <-mc:
+			if !ok {
+				c.resetClient()
+				client.Stop()
+				return errors.New("websocket message channel closed")
+			}
+			//log.Debug("receive message", zap.Any("data", msg))
+			atomic.AddUint64(&c.messages, 1)
+			c.ex.dispatch(c, msg)
+		}
+	}
+}
+
+//subscribeAll subscribes the topics of all markets and makes client the live client
+func (c *connection) subscribeAll(client wsClient) error {
+	c.ex.lock.Lock()
+	defer c.ex.lock.Unlock()
+
+	markets := c.ex.markets.all()
+	channels := make([]*sdk.WebSocketSubscribeMessage, 0, len(markets))
+	for _, m := range markets {
+		log.Info("subscribe: "+m.topic, zap.Int("connection", c.id))
+		channels = append(channels, sdk.NewSubscribeMessage(m.topic, false))
+	}
+	if err := client.Subscribe(channels...); err != nil {
+		return err
+	}
+	log.Info("Subscribe finish, connection: "+strconv.Itoa(c.id), zap.Int("topics", len(channels)))
+
+	c.client = client
+	c.live.Store(liveClient{client})
+	atomic.StoreInt64(&c.connectedTime, time.Now().UnixNano())
+	return nil
+}
+
+func (c *connection) resetClient() {
+	c.ex.lock.Lock()
+	c.client = nil
+	c.live.Store(liveClient{})
+	atomic.StoreInt64(&c.connectedTime, 0)
+	c.ex.lock.Unlock()
+}
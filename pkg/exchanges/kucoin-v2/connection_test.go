@@ -0,0 +1,106 @@
+package kucoin_v2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/orderbook"
+)
+
+func TestBackoff(t *testing.T) {
+	b := newBackoff(10*time.Millisecond, 80*time.Millisecond)
+	for i, max := range []time.Duration{10, 20, 40, 80, 80, 80} {
+		max *= time.Millisecond
+		if d := b.next(); d < max/2 || d > max {
+			t.Errorf("delay %d should be in [%s, %s], got %s", i, max/2, max, d)
+		}
+	}
+
+	b.reset()
+	if d := b.next(); d < 5*time.Millisecond || d > 10*time.Millisecond {
+		t.Errorf("the delay should start over after reset, got %s", d)
+	}
+}
+
+//testConnection runs with the fake client, its reconnect delays are sent to sleeps
+func testConnection(ex *Exchange, client *fakeClient, sleeps chan time.Duration) *connection {
+	c := newConnection(len(ex.conns), ex)
+	c.newClient = func() (wsClient, error) {
+		return client, nil
+	}
+	c.sleep = func(d time.Duration) {
+		sleeps <- d
+	}
+	ex.conns = append(ex.conns, c)
+	return c
+}
+
+func waitConnected(t *testing.T, c *connection) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !c.connected() {
+		if time.Now().After(deadline) {
+			t.Fatalf("connection %d should be connected", c.id)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestReconnectResync(t *testing.T) {
+	defer func(min, max time.Duration) {
+		defaultConfig.ReconnectMinDelay, defaultConfig.ReconnectMaxDelay = min, max
+	}(defaultConfig.ReconnectMinDelay, defaultConfig.ReconnectMaxDelay)
+	defaultConfig.ReconnectMinDelay, defaultConfig.ReconnectMaxDelay = 10*time.Millisecond, 40*time.Millisecond
+
+	ex := newTestExchange()
+	m := newMarket(nil, "KCS-USDT")
+	if err := ex.markets.add(m); err != nil {
+		t.Fatal(err)
+	}
+	resyncing := func() bool {
+		state, _ := m.ob.Status()
+		return state == orderbook.StateResyncing
+	}
+
+	clients := []*fakeClient{newFakeClient(), newFakeClient()}
+	sleeps := []chan time.Duration{make(chan time.Duration), make(chan time.Duration)}
+	conns := []*connection{
+		testConnection(ex, clients[0], sleeps[0]),
+		testConnection(ex, clients[1], sleeps[1]),
+	}
+
+	go conns[0].run()
+	waitConnected(t, conns[0])
+
+	//the delays grow until the cap while the connects fail
+	clients[1].setFailConnect(4)
+	go conns[1].run()
+	for i, max := range []time.Duration{10, 20, 40, 40} {
+		max *= time.Millisecond
+		if d := <-sleeps[1]; d < max/2 || d > max {
+			t.Errorf("delay %d should be in [%s, %s], got %s", i, max/2, max, d)
+		}
+	}
+	waitConnected(t, conns[1])
+	if resyncing() {
+		t.Fatal("the order book should not resync while another connection is alive")
+	}
+
+	//connection 0 drops and keeps failing, connection 1 is still alive
+	clients[0].setFailConnect(1 << 20)
+	clients[0].drop()
+	<-sleeps[0]
+	<-sleeps[0]
+	if resyncing() {
+		t.Fatal("the order book should not resync while another connection is alive")
+	}
+
+	//no connection is alive once connection 1 drops
+	clients[1].drop()
+	if d := <-sleeps[1]; d > 10*time.Millisecond {
+		t.Errorf("the delay should start over after a successful connect, got %s", d)
+	}
+	if !resyncing() {
+		t.Error("the order book should resync when no connection is alive")
+	}
+}
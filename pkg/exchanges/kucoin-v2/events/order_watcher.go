@@ -1,12 +1,10 @@
 package events
 
 import (
-	"encoding/json"
 	"fmt"
 	"sync"
+	"time"
 
-	"github.com/Kucoin/kucoin-level3-sdk/pkg/consts"
-	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/sdk"
 	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/stream"
 	"github.com/Kucoin/kucoin-level3-sdk/pkg/services/log"
 	"github.com/Kucoin/kucoin-level3-sdk/pkg/services/redis"
@@ -14,88 +12,183 @@ import (
 	"go.uber.org/zap"
 )
 
+//privateDoneTimeout is how long an order done on the public feed waits for its private done, before it is removed
+const privateDoneTimeout = 30 * time.Second
+
 type OrderWatcher struct {
-	Messages chan *sdk.WebSocketDownstreamMessage
-	lock     *sync.RWMutex
+	Messages        chan *stream.Event
+	PrivateMessages chan *stream.Event //order changes of our own account, Data is *stream.PrivateOrderModel
+	lock            *sync.RWMutex
+
+	//the private order changes are authoritative, watched orders are removed by them instead of the public done messages,
+	//unless the private done is missed for doneTimeout
+	privateOrders bool
+	doneTimeout   time.Duration
+	publicDones   map[string]time.Time //orderId => time of the public done, waiting for the private done
+
+	//publisher sends the message to the redis channel
+	publisher func(channel string, message string)
 
 	orderIds   map[string]map[string]bool //orderId => channel
 	clientOids map[string]map[string]bool //clientOid => channel
+
+	mappedOrderIds   map[string]string //clientOid => orderId, of the watched orders
+	mappedClientOids map[string]string //orderId => clientOid
 }
 
-func NewOrderWatcher() *OrderWatcher {
+func NewOrderWatcher(privateOrders bool, chanLen int, privateChanLen int) *OrderWatcher {
 	return &OrderWatcher{
-		Messages: make(chan *sdk.WebSocketDownstreamMessage, consts.MaxMsgChanLen),
-		lock:     &sync.RWMutex{},
+		Messages:        make(chan *stream.Event, chanLen),
+		PrivateMessages: make(chan *stream.Event, privateChanLen),
+		lock:            &sync.RWMutex{},
+
+		privateOrders: privateOrders,
+		doneTimeout:   privateDoneTimeout,
+		publicDones:   make(map[string]time.Time),
+
+		publisher: func(channel string, message string) {
+			redis.Publish("", channel, message)
+		},
 
 		orderIds:   make(map[string]map[string]bool),
 		clientOids: make(map[string]map[string]bool),
+
+		mappedOrderIds:   make(map[string]string),
+		mappedClientOids: make(map[string]string),
 	}
 }
 
 func (w *OrderWatcher) Run() {
 	log.Info("start running OrderWatcher")
 
-	for msg := range w.Messages {
-		if !w.existEventOrderIds() {
-			continue
-		}
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
 
-		l3Data, err := stream.NewStreamDataModel(msg)
-		if err != nil {
-			log.Panic("NewStreamDataModel err: " + err.Error())
-			return
-		}
+	for {
+		select {
+		case msg, ok := <-w.Messages:
+			if !ok {
+				log.Info("stop running OrderWatcher")
+				return
+			}
+			w.apply(msg)
 
-		publishedData := base.ToJsonString(msg)
-		switch l3Data.Type {
-		case stream.MessageReceivedType:
-			data := &stream.DataReceivedModel{}
-			if err := json.Unmarshal(l3Data.Data(), data); err != nil {
-				log.Panic("Unmarshal err", zap.Error(err))
+		case now := <-ticker.C:
+			if w.privateOrders {
+				w.expireDones(now)
 			}
+		}
+	}
+}
 
-			w.migrationClientOidToOrderIds(data.ClientOid, data.OrderId)
+//apply publishes the public order change to the channels watching it
+func (w *OrderWatcher) apply(msg *stream.Event) {
+	if !w.existEventOrderIds() {
+		return
+	}
 
-			w.publish(data.OrderId, publishedData)
+	publishedData := base.ToJsonString(msg.Raw)
+	switch data := msg.Data.(type) {
+	case *stream.DataReceivedModel:
+		w.migrationClientOidToOrderIds(data.ClientOid, data.OrderId)
+		w.publish(data.OrderId, publishedData)
 
-		case stream.MessageOpenType:
-			data := &stream.DataOpenModel{}
-			if err := json.Unmarshal(l3Data.Data(), data); err != nil {
-				log.Panic("Unmarshal err", zap.Error(err))
-			}
+	case *stream.FutureDataReceivedModel:
+		w.migrationClientOidToOrderIds(data.ClientOid, data.OrderId)
+		w.publish(data.OrderId, publishedData)
 
-			w.publish(data.OrderId, publishedData)
+	case *stream.DataOpenModel:
+		w.publish(data.OrderId, publishedData)
 
-		case stream.MessageMatchType:
-			data := &stream.DataMatchModel{}
-			if err := json.Unmarshal(l3Data.Data(), data); err != nil {
-				log.Panic("Unmarshal err", zap.Error(err))
-			}
+	case *stream.FutureDataOpenModel:
+		w.publish(data.OrderId, publishedData)
 
-			w.publish(data.MakerOrderId, publishedData)
-			w.publish(data.TakerOrderId, publishedData)
+	case *stream.DataMatchModel:
+		w.publish(data.MakerOrderId, publishedData)
+		w.publish(data.TakerOrderId, publishedData)
 
-		case stream.MessageDoneType:
-			data := &stream.DataDoneModel{}
-			if err := json.Unmarshal(l3Data.Data(), data); err != nil {
-				log.Panic("Unmarshal err", zap.Error(err))
-			}
+	case *stream.FutureDataMatchModel:
+		w.publish(data.MakerOrderId, publishedData)
+		w.publish(data.TakerOrderId, publishedData)
 
-			w.publish(data.OrderId, publishedData)
-			w.removeEventOrderId(data.OrderId)
+	case *stream.DataDoneModel:
+		w.publish(data.OrderId, publishedData)
+		w.done(data.OrderId)
 
-		case stream.MessageUpdateType:
-			data := &stream.DataUpdateModel{}
-			if err := json.Unmarshal(l3Data.Data(), data); err != nil {
-				log.Panic("Unmarshal err", zap.Error(err))
-			}
+	case *stream.FutureDataDoneModel:
+		w.publish(data.OrderId, publishedData)
+		w.done(data.OrderId)
+
+	case *stream.DataUpdateModel:
+		w.publish(data.OrderId, publishedData)
+
+	case *stream.FutureDataUpdateModel:
+		w.publish(data.OrderId, publishedData)
 
-			w.publish(data.OrderId, publishedData)
+	default:
+		log.Panic("error msg type: " + msg.Type)
+	}
+}
+
+//done stops watching the order done on the public feed,
+//with the private order changes it waits for the private done, which has the final fills and fees
+func (w *OrderWatcher) done(orderId string) {
+	if !w.privateOrders {
+		w.removeEventOrderId(orderId)
+		return
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
 
-		default:
-			log.Panic("error msg type: " + l3Data.Type)
+	if _, ok := w.orderIds[orderId]; ok {
+		w.publicDones[orderId] = time.Now()
+	}
+}
+
+//expireDones removes the orders done on the public feed whose private done is missed, so they do not leak
+func (w *OrderWatcher) expireDones(now time.Time) {
+	w.lock.RLock()
+	var expired []string
+	for orderId, doneTime := range w.publicDones {
+		if now.Sub(doneTime) > w.doneTimeout {
+			expired = append(expired, orderId)
 		}
 	}
+	w.lock.RUnlock()
+
+	for _, orderId := range expired {
+		log.Warn("private done is missed, remove the order done on the public feed", zap.String("orderId", orderId))
+		w.removeEventOrderId(orderId)
+	}
+}
+
+//RunPrivate publishes the order changes of our own account, including the orders never seen on the public feed,
+//with the fills, fees and status
+func (w *OrderWatcher) RunPrivate() {
+	log.Info("start running private OrderWatcher")
+
+	for msg := range w.PrivateMessages {
+		w.applyPrivate(msg)
+	}
+
+	log.Info("stop running private OrderWatcher")
+}
+
+func (w *OrderWatcher) applyPrivate(msg *stream.Event) {
+	if !w.existEventOrderIds() {
+		return
+	}
+
+	order := msg.Data.(*stream.PrivateOrderModel)
+	if order.ClientOid != "" {
+		w.migrationClientOidToOrderIds(order.ClientOid, order.OrderId)
+	}
+
+	w.publish(order.OrderId, base.ToJsonString(msg.Raw))
+	if order.Done() {
+		w.removeEventOrderId(order.OrderId)
+	}
 }
 
 func (w *OrderWatcher) migrationClientOidToOrderIds(clientOid, orderId string) {
@@ -111,9 +204,23 @@ func (w *OrderWatcher) migrationClientOidToOrderIds(clientOid, orderId string) {
 		w.AddEventOrderIdsToChannels(map[string][]string{
 			orderId: channels,
 		})
+
+		w.lock.Lock()
+		w.mappedOrderIds[clientOid] = orderId
+		w.mappedClientOids[orderId] = clientOid
+		w.lock.Unlock()
 	}
 }
 
+//OrderId returns the orderId of a watched clientOid, once the order is received
+func (w *OrderWatcher) OrderId(clientOid string) (string, bool) {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	orderId, ok := w.mappedOrderIds[clientOid]
+	return orderId, ok
+}
+
 func getMapKeys(data map[string]bool) []string {
 	keys := make([]string, 0, len(data))
 	for k := range data {
@@ -131,7 +238,7 @@ func (w *OrderWatcher) publish(orderId string, message string) {
 
 	if ok {
 		for _, channel := range channels {
-			redis.Publish("", channel, message)
+			w.publisher(channel, message)
 		}
 	}
 }
@@ -182,6 +289,11 @@ func (w *OrderWatcher) removeEventOrderId(orderId string) {
 	defer w.lock.Unlock()
 
 	delete(w.orderIds, orderId)
+	delete(w.publicDones, orderId)
+	if clientOid, ok := w.mappedClientOids[orderId]; ok {
+		delete(w.mappedClientOids, orderId)
+		delete(w.mappedOrderIds, clientOid)
+	}
 }
 
 func (w *OrderWatcher) removeEventClientOid(clientOid string) {
@@ -0,0 +1,130 @@
+package events
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/sdk"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/stream"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/services/log"
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	_ = log.SetLogger(zap.NewNop())
+	os.Exit(m.Run())
+}
+
+//newTestWatcher returns a watcher of the private order changes, whose published messages are counted by orderId
+func newTestWatcher(t *testing.T) (*OrderWatcher, func(orderId string) int) {
+	w := NewOrderWatcher(true, 10, 10)
+	lock := &sync.Mutex{}
+	published := make(map[string]int)
+	w.publisher = func(channel string, message string) {
+		var msg sdk.WebSocketDownstreamMessage
+		if err := json.Unmarshal([]byte(message), &msg); err != nil {
+			t.Fatal(err)
+		}
+		var data struct {
+			OrderId string `json:"orderId"`
+		}
+		if err := json.Unmarshal(msg.RawData, &data); err != nil {
+			t.Fatal(err)
+		}
+
+		lock.Lock()
+		published[data.OrderId]++
+		lock.Unlock()
+	}
+
+	return w, func(orderId string) int {
+		lock.Lock()
+		defer lock.Unlock()
+		return published[orderId]
+	}
+}
+
+func privateEvent(t *testing.T, orderId string, changeType string) *stream.Event {
+	raw, _ := json.Marshal(map[string]interface{}{"symbol": "KCS-USDT", "orderId": orderId, "type": changeType, "ts": 1})
+	event, err := stream.NewPrivateEvent(&sdk.WebSocketDownstreamMessage{Subject: "orderChange", RawData: raw})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+func publicDone(t *testing.T, orderId string) *stream.Event {
+	raw, _ := json.Marshal(map[string]interface{}{"sequence": 1, "orderId": orderId, "ts": 1})
+	event, err := stream.NewEvent(&sdk.WebSocketDownstreamMessage{Subject: stream.MessageDoneType, RawData: raw}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+func (w *OrderWatcher) watched(orderId string) bool {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	_, ok := w.orderIds[orderId]
+	return ok
+}
+
+func TestPrivateOrderChanges(t *testing.T) {
+	w, published := newTestWatcher(t)
+	w.AddEventOrderIdsToChannels(map[string][]string{"a": {"channel"}, "b": {"channel"}})
+
+	w.applyPrivate(privateEvent(t, "a", stream.PrivateOrderMatchType))
+	w.applyPrivate(privateEvent(t, "a", stream.PrivateOrderFilledType))
+	if published("a") != 2 || w.watched("a") {
+		t.Errorf("the private changes should be published and the private done should remove the order, published: %d", published("a"))
+	}
+
+	//the public done arriving after the private done is neither published nor waiting
+	w.apply(publicDone(t, "a"))
+	if published("a") != 2 || w.watched("a") || len(w.publicDones) != 0 {
+		t.Errorf("the public done after the private done should be ignored, published: %d, waiting: %v", published("a"), w.publicDones)
+	}
+
+	//the public done waits for the private done, which removes the order
+	w.apply(publicDone(t, "b"))
+	if !w.watched("b") {
+		t.Fatal("the order should be watched until its private done")
+	}
+	w.applyPrivate(privateEvent(t, "b", stream.PrivateOrderCanceledType))
+	if published("b") != 2 || w.watched("b") || len(w.publicDones) != 0 {
+		t.Errorf("the private done should remove the order done on the public feed, published: %d", published("b"))
+	}
+}
+
+func TestPrivateDoneMissed(t *testing.T) {
+	w, published := newTestWatcher(t)
+	w.doneTimeout = time.Minute
+	w.AddEventOrderIdsToChannels(map[string][]string{"a": {"channel"}})
+
+	now := time.Now()
+	w.apply(publicDone(t, "a"))
+	w.expireDones(now.Add(30 * time.Second))
+	if !w.watched("a") || published("a") != 1 {
+		t.Fatalf("the order should wait for its private done within doneTimeout, published: %d", published("a"))
+	}
+
+	w.expireDones(now.Add(time.Minute + time.Second))
+	if w.watched("a") || len(w.publicDones) != 0 {
+		t.Error("the order should be removed once its private done is missed for doneTimeout")
+	}
+	w.applyPrivate(privateEvent(t, "a", stream.PrivateOrderCanceledType))
+	if published("a") != 1 {
+		t.Errorf("the late private done of the removed order should not be published, published: %d", published("a"))
+	}
+
+	//the public done of an unwatched order is not remembered
+	w.AddEventOrderIdsToChannels(map[string][]string{"b": {"channel"}})
+	w.apply(publicDone(t, "c"))
+	if len(w.publicDones) != 0 {
+		t.Errorf("only the watched orders should wait, got %v", w.publicDones)
+	}
+}
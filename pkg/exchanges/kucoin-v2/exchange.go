@@ -4,14 +4,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/Kucoin/kucoin-level3-sdk/pkg/cfg"
 	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges"
-	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/events"
-	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/orderbook"
 	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/sdk"
-	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/verify"
 	"github.com/Kucoin/kucoin-level3-sdk/pkg/services/log"
 	"go.uber.org/zap"
 )
@@ -20,9 +19,13 @@ type Exchange struct {
 	exchanges.BasicExchange
 
 	apiService *sdk.Kucoin
-	ob         *orderbook.Builder
-	ow         *events.OrderWatcher
-	verify     *verify.Verify
+	markets    *registry
+
+	lock          *sync.Mutex //guard the clients of the connections and the markets they subscribe
+	subscribeLock *sync.Mutex //serialize Subscribe and Unsubscribe
+	conns         []*connection
+
+	checkSymbol func(symbol string) error //reports an error unless the exchange lists the symbol
 }
 
 func newExchange() *Exchange {
@@ -36,95 +39,293 @@ func newExchange() *Exchange {
 		30*time.Second,
 	)
 
-	build := orderbook.NewBuilder(apiService, cfg.AppConfig.Symbol)
-	var verifyObj *verify.Verify
-	//if defaultConfig.Verify {
-	//	verifyObj = verify.NewVerify(build, 20, defaultConfig.VerifyDir, cfg.AppConfig.Symbol)
-	//}
 	ex := &Exchange{
 		apiService: apiService,
-		ob:         build,
-		ow:         events.NewOrderWatcher(),
-		verify:     verifyObj,
+		markets:    newRegistry(),
+		lock:       &sync.Mutex{},
+
+		subscribeLock: &sync.Mutex{},
+		checkSymbol: func(symbol string) error {
+			_, _, _, err := apiService.SymbolIncrements(symbol)
+			return err
+		},
+	}
+	for i := 0; i < defaultConfig.Connections; i++ {
+		ex.conns = append(ex.conns, newConnection(i, ex))
 	}
 
-	//init ob
-	go ex.ob.ReloadOrderBook()
+	for _, symbol := range cfg.AppConfig.Symbols {
+		m := newMarket(apiService, symbol)
+		if err := ex.markets.add(m); err != nil {
+			log.Panic("add market panic", zap.Error(err))
+		}
+		m.run()
+	}
 
-	go ex.ow.Run()
+	for _, c := range ex.conns {
+		go c.run()
+	}
 
-	//if defaultConfig.Verify {
-	//	go ex.verify.Run()
-	//}
+	if defaultConfig.PrivateOrders {
+		go newPrivateConnection(ex).run()
+	}
 
-	go ex.websocket()
+	go ex.watchdog()
+	go ex.monitorConsumers()
 
 	return ex
 }
 
-func (ex *Exchange) websocket() {
-	tk, err := ex.apiService.WebSocketPublicToken()
+func (ex *Exchange) anyConnected() bool {
+	for _, c := range ex.conns {
+		if c.connected() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (ex *Exchange) dispatch(c *connection, msgRawData *sdk.WebSocketDownstreamMessage) {
+	//log.Debug("raw message : " + base.ToJsonString(msgRawData))
+	if !ex.markets.dispatch(c.id, msgRawData) {
+		log.Warn("dispatch message of unknown topic: " + msgRawData.Topic)
+	}
+}
+
+//Subscribe checks the symbol exists, starts its order book and subscribes its topic on the live websocket clients,
+//ex.lock is released while waiting for the acks, so the connections reconnect meanwhile
+func (ex *Exchange) Subscribe(symbol string) error {
+	ex.subscribeLock.Lock()
+	defer ex.subscribeLock.Unlock()
+
+	//a typo'd symbol would fail the subscribe on the connections shared by all markets
+	if err := ex.checkSymbol(symbol); err != nil {
+		return fmt.Errorf("%w: %s, %v", exchanges.ErrSymbolNotFound, symbol, err)
+	}
+
+	m := newMarket(ex.apiService, symbol)
+	clients, err := ex.addMarket(m)
+	if err != nil {
+		return err
+	}
+	m.run()
+
+	subscribed := make([]*connection, 0, len(clients))
+	for i, c := range ex.conns {
+		client := clients[i]
+		if client == nil {
+			continue
+		}
+
+		log.Info("subscribe: "+m.topic, zap.Int("connection", c.id))
+		if err := client.Subscribe(sdk.NewSubscribeMessage(m.topic, false)); err != nil {
+			if c.currentClient() != client {
+				//reconnected meanwhile, the new client subscribes the topics of all markets
+				log.Warn("subscribe error of a replaced client: "+m.topic, zap.Int("connection", c.id), zap.Error(err))
+				continue
+			}
+
+			//roll back the topic subscribed on the other connections
+			for _, s := range subscribed {
+				log.Info("unsubscribe: "+m.topic, zap.Int("connection", s.id))
+				if err := clients[s.id].Unsubscribe(sdk.NewUnsubscribeMessage(m.topic, false)); err != nil {
+					log.Error("rollback unsubscribe error: "+m.topic, zap.Int("connection", s.id), zap.Error(err))
+				}
+			}
+			if _, _, err := ex.removeMarket(symbol); err == nil {
+				m.stop()
+			}
+			return err
+		}
+		subscribed = append(subscribed, c)
+	}
+
+	return nil
+}
+
+//Unsubscribe stops the order book of the symbol and unsubscribes its topic on every live websocket client,
+//the failures are logged and combined into the returned error
+func (ex *Exchange) Unsubscribe(symbol string) error {
+	ex.subscribeLock.Lock()
+	defer ex.subscribeLock.Unlock()
+
+	m, clients, err := ex.removeMarket(symbol)
 	if err != nil {
-		log.Panic("WebSocketPublicToken err", zap.Error(err))
+		return err
+	}
+	m.stop()
+
+	var failures []string
+	for i, c := range ex.conns {
+		client := clients[i]
+		if client == nil {
+			continue
+		}
+
+		log.Info("unsubscribe: "+m.topic, zap.Int("connection", c.id))
+		if err := client.Unsubscribe(sdk.NewUnsubscribeMessage(m.topic, false)); err != nil {
+			log.Error("unsubscribe error: "+m.topic, zap.Int("connection", c.id), zap.Error(err))
+			failures = append(failures, fmt.Sprintf("connection %d: %v", c.id, err))
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("unsubscribe %s failed, %s", m.topic, strings.Join(failures, "; "))
+	}
+
+	return nil
+}
+
+//addMarket registers the market and returns the live clients of the connections, by connection id,
+//a connection subscribing its topics later subscribes the topic of the market too
+func (ex *Exchange) addMarket(m *market) ([]wsClient, error) {
+	ex.lock.Lock()
+	defer ex.lock.Unlock()
+
+	if err := ex.markets.add(m); err != nil {
+		return nil, err
 	}
 
-	c := ex.apiService.NewWebSocketClient(tk)
+	return ex.liveClients(), nil
+}
+
+//removeMarket removes the market of the symbol and returns the live clients of the connections, by connection id
+func (ex *Exchange) removeMarket(symbol string) (*market, []wsClient, error) {
+	ex.lock.Lock()
+	defer ex.lock.Unlock()
 
-	mc, ec, err := c.Connect()
+	m, err := ex.markets.remove(symbol)
 	if err != nil {
-		log.Panic("Connect panic: " + err.Error())
+		return nil, nil, err
+	}
+
+	return m, ex.liveClients(), nil
+}
+
+//liveClients returns the clients of the connections, nil of a disconnected one, the caller holds ex.lock
+func (ex *Exchange) liveClients() []wsClient {
+	clients := make([]wsClient, len(ex.conns))
+	for i, c := range ex.conns {
+		clients[i] = c.client
 	}
-	topic := sdk.L3TopicPrefix(defaultConfig.Type) + cfg.AppConfig.Symbol
-	ch := sdk.NewSubscribeMessage(topic, false)
-	log.Info("subscribe: " + topic)
-	if err := c.Subscribe(ch); err != nil {
-		log.Panic("Subscribe panic: "+err.Error(), zap.Error(err))
+
+	return clients
+}
+
+func (ex *Exchange) ListSymbols() ([]*exchanges.SymbolStatus, error) {
+	markets := ex.markets.all()
+	ret := make([]*exchanges.SymbolStatus, 0, len(markets))
+	for _, m := range markets {
+		ret = append(ret, m.status())
 	}
-	log.Info("Subscribe finish", zap.String("topic", topic))
-	for {
-		select {
-		case err := <-ec:
-			c.Stop() // Stop subscribing the WebSocket feed
-			log.Panic("Connect panic: " + err.Error())
 
-		case msg := <-mc:
-			//log.Debug("receive message", zap.Any("data", msg))
-			ex.dispatch(msg)
+	return ret, nil
+}
+
+//monitorConsumers reports the consumers falling behind, the channel depths are listed by ListSymbols
+func (ex *Exchange) monitorConsumers() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		for _, m := range ex.markets.all() {
+			m.bus.check()
+			m.private.check()
 		}
 	}
 }
 
-func (ex *Exchange) dispatch(msgRawData *sdk.WebSocketDownstreamMessage) {
-	//log.Debug("raw message : " + base.ToJsonString(msgRawData))
-	ex.ob.Messages <- msgRawData
-	ex.ow.Messages <- msgRawData
-	//if defaultConfig.Verify {
-	//	ex.verify.Messages <- msgRawData
-	//}
+func (ex Exchange) GetPartOrderBook(symbol string, number int) (*exchanges.OrderBook, error) {
+	m, err := ex.markets.get(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.ob.GetPartOrderBook(number)
+}
+
+func (ex Exchange) GetAggregatedOrderBook(symbol string, number int, step string) (*exchanges.OrderBook, error) {
+	m, err := ex.markets.get(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.ob.GetAggregatedOrderBook(number, step)
 }
 
-func (ex *Exchange) monitorChanLen() {
-	for {
-		const msgLenLimit = 50
-		if len(ex.ob.Messages) > msgLenLimit {
-			log.Info(fmt.Sprintf(
-				"msgLenLimit: ex.ob.Messages: %d",
-				len(ex.ob.Messages),
-			))
+func (ex Exchange) AddEventClientOidsToChannels(symbol string, data map[string][]string) error {
+	m, err := ex.markets.get(symbol)
+	if err != nil {
+		return err
+	}
+
+	return m.ow.AddEventClientOidsToChannels(data)
+}
+
+//GetQueuePosition looks up the order by orderId, or by a clientOid mapped by the order watcher
+func (ex Exchange) GetQueuePosition(symbol string, orderId string, clientOid string) (*exchanges.QueuePosition, error) {
+	m, err := ex.markets.get(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	if orderId == "" {
+		if clientOid == "" {
+			return nil, errors.New("empty orderId and clientOid")
+		}
+
+		var ok bool
+		if orderId, ok = m.ow.OrderId(clientOid); !ok {
+			return nil, fmt.Errorf("%w, symbol: %s, clientOid is not mapped: %s", exchanges.ErrOrderNotFound, symbol, clientOid)
 		}
-		time.Sleep(time.Second)
 	}
+
+	data, err := m.ob.GetQueuePosition(orderId)
+	if data != nil && clientOid != "" {
+		data.ClientOid = clientOid
+	}
+
+	return data, err
+}
+
+//anyData returns the data of an AnyCall as an interface, nil instead of a typed nil pointer,
+//so the rpc response of an error carries no data
+func anyData[T any](data *T, err error) (interface{}, error) {
+	if data == nil {
+		return nil, err
+	}
+
+	return data, err
+}
+
+func (ex Exchange) GetMarketImpact(symbol string, order *exchanges.MarketOrder) (*exchanges.MarketImpact, error) {
+	m, err := ex.markets.get(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.ob.GetMarketImpact(order)
 }
 
-func (ex Exchange) GetPartOrderBook(number int) *exchanges.OrderBook {
-	return ex.ob.GetPartOrderBook(number)
+func (ex Exchange) GetBookStats(symbol string) (*exchanges.BookStats, error) {
+	m, err := ex.markets.get(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.ob.GetBookStats()
 }
 
-func (ex Exchange) AddEventClientOidsToChannels(data map[string][]string) error {
-	return ex.ow.AddEventClientOidsToChannels(data)
+func (ex Exchange) GetOrderBookRange(symbol string, query *exchanges.BookRangeQuery) (*exchanges.OrderBook, error) {
+	m, err := ex.markets.get(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.ob.GetOrderBookRange(query)
 }
 
-func (ex Exchange) AnyCall(method string, args json.RawMessage) (ret interface{}, err error) {
+func (ex Exchange) AnyCall(symbol string, method string, args json.RawMessage) (ret interface{}, err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			log.Error("AnyCall panic", zap.Any("r", r))
@@ -134,17 +335,65 @@ func (ex Exchange) AnyCall(method string, args json.RawMessage) (ret interface{}
 		}
 	}()
 
+	m, err := ex.markets.get(symbol)
+	if err != nil {
+		return nil, err
+	}
+
 	switch method {
 	case "GetL3PartOrderBook":
 		type AnyCallArgs struct {
-			Number int `json:"number"`
+			Number   int  `json:"number"`
+			Extended bool `json:"extended"` //the orders with their times, fills and age
+		}
+		var anyCallArgs AnyCallArgs
+		if err := json.Unmarshal(args, &anyCallArgs); err != nil {
+			return nil, errors.New("unmarshal AnyCallArgs error: " + string(args))
+		}
+
+		if anyCallArgs.Extended {
+			return anyData(m.ob.GetL3ExtendedPartOrderBook(anyCallArgs.Number))
+		}
+
+		return anyData(m.ob.GetL3PartOrderBook(anyCallArgs.Number))
+	case "GetL3OrderBookRange":
+		var query exchanges.BookRangeQuery
+		if err := json.Unmarshal(args, &query); err != nil {
+			return nil, errors.New("unmarshal AnyCallArgs error: " + string(args))
+		}
+
+		return anyData(m.ob.GetL3OrderBookRange(&query))
+	case "GetQueuePosition":
+		type AnyCallArgs struct {
+			OrderId   string `json:"orderId"`
+			ClientOid string `json:"clientOid"`
 		}
 		var anyCallArgs AnyCallArgs
 		if err := json.Unmarshal(args, &anyCallArgs); err != nil {
 			return nil, errors.New("unmarshal AnyCallArgs error: " + string(args))
 		}
 
-		return ex.ob.GetL3PartOrderBook(anyCallArgs.Number), nil
+		return anyData(ex.GetQueuePosition(symbol, anyCallArgs.OrderId, anyCallArgs.ClientOid))
+	case "GetMarketImpact":
+		var order exchanges.MarketOrder
+		if err := json.Unmarshal(args, &order); err != nil {
+			return nil, errors.New("unmarshal AnyCallArgs error: " + string(args))
+		}
+
+		return anyData(m.ob.GetMarketImpact(&order))
+	case "GetBookStats":
+		return anyData(m.ob.GetBookStats())
+	case "GetL3BinarySnapshot":
+		return anyData(m.ob.GetL3BinarySnapshot())
+	case "GetSyncStats":
+		return m.ob.SyncStats(), nil
+	case "GetConnectionStats":
+		stats := make([]*ConnectionStats, 0, len(ex.conns))
+		for _, c := range ex.conns {
+			stats = append(stats, c.stats(m))
+		}
+
+		return stats, nil
 	default:
 		return nil, errors.New("unsupported rpc method: " + method)
 	}
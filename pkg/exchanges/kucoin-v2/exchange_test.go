@@ -0,0 +1,316 @@
+package kucoin_v2
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/sdk"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/stream"
+)
+
+//fakeClient records the subscribed topics, a connected fake fails on drop
+type fakeClient struct {
+	lock            *sync.Mutex
+	topics          map[string]bool
+	failSubscribe   bool
+	failUnsubscribe bool
+	acks            chan struct{} //the subscribe waits for an ack from it if not nil
+	failConnect     int           //the next connects failing
+	errs            chan error
+	lastReceive     int64
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{
+		lock:   &sync.Mutex{},
+		topics: make(map[string]bool),
+		errs:   make(chan error),
+	}
+}
+
+func (f *fakeClient) Connect() (<-chan *sdk.WebSocketDownstreamMessage, <-chan error, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.failConnect > 0 {
+		f.failConnect--
+		return nil, nil, errors.New("connect failed")
+	}
+	return make(chan *sdk.WebSocketDownstreamMessage), f.errs, nil
+}
+
+func (f *fakeClient) Subscribe(channels ...*sdk.WebSocketSubscribeMessage) error {
+	if f.acks != nil {
+		<-f.acks
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.failSubscribe {
+		return errors.New("subscribe failed")
+	}
+	for _, c := range channels {
+		f.topics[c.Topic] = true
+	}
+	return nil
+}
+
+func (f *fakeClient) Unsubscribe(channels ...*sdk.WebSocketUnsubscribeMessage) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.failUnsubscribe {
+		return errors.New("unsubscribe failed")
+	}
+	for _, c := range channels {
+		delete(f.topics, c.Topic)
+	}
+	return nil
+}
+
+func (f *fakeClient) Stop() {}
+
+func (f *fakeClient) LastReceiveTime() int64 {
+	return atomic.LoadInt64(&f.lastReceive)
+}
+
+func (f *fakeClient) setFailConnect(n int) {
+	f.lock.Lock()
+	f.failConnect = n
+	f.lock.Unlock()
+}
+
+//drop fails the connection of the fake
+func (f *fakeClient) drop() {
+	f.errs <- errors.New("connection dropped")
+}
+
+func (f *fakeClient) subscribed(topic string) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.topics[topic]
+}
+
+//newTestExchange returns an exchange of the connected fake clients, without any goroutine
+func newTestExchange(clients ...*fakeClient) *Exchange {
+	ex := &Exchange{
+		markets: newRegistry(),
+		lock:    &sync.Mutex{},
+
+		subscribeLock: &sync.Mutex{},
+		checkSymbol: func(symbol string) error {
+			if symbol == "UNKNOWN-USDT" {
+				return errors.New("symbol not found")
+			}
+			return nil
+		},
+	}
+	for i, client := range clients {
+		c := newConnection(i, ex)
+		c.client = client
+		c.live.Store(liveClient{client})
+		c.connectedTime = time.Now().UnixNano()
+		ex.conns = append(ex.conns, c)
+	}
+
+	return ex
+}
+
+func TestSubscribeUnsubscribe(t *testing.T) {
+	clients := []*fakeClient{newFakeClient(), newFakeClient()}
+	ex := newTestExchange(clients...)
+	topic := sdk.L3TopicPrefix("spot") + "KCS-USDT"
+
+	if err := ex.Subscribe("KCS-USDT"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ex.Subscribe("KCS-USDT"); err == nil {
+		t.Errorf("subscribing a symbol twice should fail")
+	}
+	for i, client := range clients {
+		if !client.subscribed(topic) {
+			t.Errorf("the topic should be subscribed on connection %d", i)
+		}
+	}
+	m, err := ex.markets.get("KCS-USDT")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ex.Unsubscribe("KCS-USDT"); err != nil {
+		t.Fatal(err)
+	}
+	for i, client := range clients {
+		if client.subscribed(topic) {
+			t.Errorf("the topic should be unsubscribed on connection %d", i)
+		}
+	}
+	if symbols, _ := ex.ListSymbols(); len(symbols) != 0 {
+		t.Errorf("the symbol should be removed, got %v", symbols)
+	}
+	if _, ok := <-m.ob.Messages; ok {
+		t.Errorf("the channels of the market should be closed")
+	}
+	if err := ex.Unsubscribe("KCS-USDT"); err == nil {
+		t.Errorf("unsubscribing an unknown symbol should fail")
+	}
+}
+
+func TestSubscribeRollback(t *testing.T) {
+	clients := []*fakeClient{newFakeClient(), newFakeClient(), newFakeClient()}
+	clients[1].failSubscribe = true
+	ex := newTestExchange(clients...)
+	topic := sdk.L3TopicPrefix("spot") + "KCS-USDT"
+
+	if err := ex.Subscribe("KCS-USDT"); err == nil {
+		t.Fatal("the subscribe should fail")
+	}
+	for i, client := range clients {
+		if client.subscribed(topic) {
+			t.Errorf("the topic should be rolled back on connection %d", i)
+		}
+	}
+	if _, err := ex.markets.get("KCS-USDT"); err == nil {
+		t.Errorf("the market should be removed")
+	}
+
+	clients[1].failSubscribe = false
+	if err := ex.Subscribe("KCS-USDT"); err != nil {
+		t.Errorf("the symbol should be subscribed again after the rollback: %v", err)
+	}
+	ex.Unsubscribe("KCS-USDT")
+}
+
+func TestSubscribeUnknownSymbol(t *testing.T) {
+	client := newFakeClient()
+	ex := newTestExchange(client)
+
+	err := ex.Subscribe("UNKNOWN-USDT")
+	if !errors.Is(err, exchanges.ErrSymbolNotFound) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if client.subscribed(sdk.L3TopicPrefix("spot") + "UNKNOWN-USDT") {
+		t.Errorf("an unknown symbol should not be subscribed")
+	}
+	if _, err := ex.markets.get("UNKNOWN-USDT"); err == nil {
+		t.Errorf("the market of an unknown symbol should not be added")
+	}
+}
+
+//TestSubscribeUnlockedWaitingAck resets a connection while the subscribe waits for the ack of its client
+func TestSubscribeUnlockedWaitingAck(t *testing.T) {
+	client := newFakeClient()
+	client.acks = make(chan struct{})
+	ex := newTestExchange(client)
+
+	subscribed := make(chan error)
+	go func() {
+		subscribed <- ex.Subscribe("KCS-USDT")
+	}()
+	//the market is added with the snapshot of the clients under the lock
+	for _, err := ex.markets.get("KCS-USDT"); err != nil; _, err = ex.markets.get("KCS-USDT") {
+		time.Sleep(time.Millisecond)
+	}
+
+	reset := make(chan struct{})
+	go func() {
+		defer close(reset)
+		ex.conns[0].resetClient()
+	}()
+	select {
+	case <-reset:
+	case <-time.After(time.Second):
+		t.Fatal("the connection should not wait for the ack of the subscribe")
+	}
+
+	//the failed ack of the replaced client does not roll back the market, the new client subscribes it
+	close(client.acks)
+	client.lock.Lock()
+	client.failSubscribe = true
+	client.lock.Unlock()
+	if err := <-subscribed; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ex.markets.get("KCS-USDT"); err != nil {
+		t.Errorf("the market should stay: %v", err)
+	}
+	ex.Unsubscribe("KCS-USDT")
+}
+
+//TestUnsubscribeEveryConnection unsubscribes the topic on the other connections after a failure
+func TestUnsubscribeEveryConnection(t *testing.T) {
+	clients := []*fakeClient{newFakeClient(), newFakeClient(), newFakeClient()}
+	ex := newTestExchange(clients...)
+	topic := sdk.L3TopicPrefix("spot") + "KCS-USDT"
+	if err := ex.Subscribe("KCS-USDT"); err != nil {
+		t.Fatal(err)
+	}
+
+	clients[0].failUnsubscribe = true
+	clients[1].failUnsubscribe = true
+	err := ex.Unsubscribe("KCS-USDT")
+	if err == nil || !strings.Contains(err.Error(), "connection 0") || !strings.Contains(err.Error(), "connection 1") {
+		t.Fatalf("the error should combine the failures, got %v", err)
+	}
+	if clients[2].subscribed(topic) {
+		t.Errorf("the topic should be unsubscribed on the connection after the failures")
+	}
+	if _, err := ex.markets.get("KCS-USDT"); err == nil {
+		t.Errorf("the market should be removed")
+	}
+}
+
+func TestDispatchBlockedOutsideRegistry(t *testing.T) {
+	defer func(consumers map[string]ConsumerConfig) { defaultConfig.Consumers = consumers }(defaultConfig.Consumers)
+	defaultConfig.Consumers = map[string]ConsumerConfig{"orderbook": {ChanLen: 1}}
+
+	ex := newTestExchange(newFakeClient())
+	m := newMarket(nil, "KCS-USDT")
+	if err := ex.markets.add(m); err != nil {
+		t.Fatal(err)
+	}
+
+	//nobody reads the order book channel, the second message blocks the dispatch
+	dispatched := make(chan struct{})
+	go func() {
+		defer close(dispatched)
+		for sequence := 1; sequence <= 2; sequence++ {
+			raw, _ := json.Marshal(map[string]interface{}{"sequence": sequence, "orderId": "a", "ts": sequence})
+			ex.dispatch(ex.conns[0], &sdk.WebSocketDownstreamMessage{Topic: m.topic, Subject: stream.MessageDoneType, RawData: raw})
+		}
+	}()
+	for len(m.ob.Messages) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	done := make(chan error)
+	go func() {
+		if _, err := ex.markets.get("KCS-USDT"); err != nil {
+			done <- err
+			return
+		}
+		done <- ex.Unsubscribe("KCS-USDT")
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the registry should not be locked by a blocked dispatch")
+	}
+
+	select {
+	case <-dispatched:
+	case <-time.After(time.Second):
+		t.Fatal("the blocked dispatch should return once the market is stopped")
+	}
+}
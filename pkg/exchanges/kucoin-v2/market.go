@@ -0,0 +1,323 @@
+package kucoin_v2
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/events"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/orderbook"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/sdk"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/stream"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/verify"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/services/log"
+	"go.uber.org/zap"
+)
+
+//market is the order book builder and order watcher of one symbol
+type market struct {
+	symbol string
+	topic  string
+	ob     *orderbook.Builder
+	ow     *events.OrderWatcher
+	verify *verify.Verify
+
+	lastMessageTime int64 //unix nano, the creation time before any message
+
+	dispatchLock *sync.Mutex
+	bus          *eventBus       //the consumers of the decoded messages
+	private      *eventBus       //the consumers of the decoded private order changes
+	filter       *sequenceFilter //pass the first arrival of each sequence among the connections
+	firsts       []uint64        //messages delivered first, by connection id
+	duplicates   []uint64        //messages delivered by another connection first, by connection id
+}
+
+func newMarket(apiService *sdk.Kucoin, symbol string) *market {
+	obConfig, owConfig, privateConfig := consumerConfig("orderbook"), consumerConfig("order_watcher"), consumerConfig("private_orders")
+	build := orderbook.NewBuilder(apiService, symbol, orderbook.Options{
+		ReorderWindow:  defaultConfig.ReorderWindow,
+		ReorderTimeout: defaultConfig.ReorderTimeout,
+		Future:         defaultConfig.Type == "future",
+		StatsDepth:     defaultConfig.StatsDepth,
+		StatsBands:     defaultConfig.StatsBands,
+		ViewDepth:      defaultConfig.ViewDepth,
+		ChanLen:        obConfig.ChanLen,
+
+		CheckpointDir:      defaultConfig.CheckpointDir,
+		CheckpointInterval: defaultConfig.CheckpointInterval,
+	})
+	var verifyObj *verify.Verify
+	//if defaultConfig.Verify {
+	//	verifyObj = verify.NewVerify(build, 20, defaultConfig.VerifyDir, symbol)
+	//}
+
+	m := &market{
+		symbol: symbol,
+		topic:  sdk.L3TopicPrefix(defaultConfig.Type) + symbol,
+		ob:     build,
+		ow:     events.NewOrderWatcher(defaultConfig.PrivateOrders, owConfig.ChanLen, privateConfig.ChanLen),
+		verify: verifyObj,
+
+		lastMessageTime: time.Now().UnixNano(),
+
+		dispatchLock: &sync.Mutex{},
+		bus:          newEventBus(symbol, streamDecoder(defaultConfig.Type == "future"), defaultConfig.SpillDir),
+		private:      newEventBus(symbol, stream.NewPrivateEvent, defaultConfig.SpillDir),
+		filter:       newSequenceFilter(),
+		firsts:       make([]uint64, defaultConfig.Connections),
+		duplicates:   make([]uint64, defaultConfig.Connections),
+	}
+
+	m.bus.subscribe("orderbook", m.ob.Messages, obConfig, m.ob.Resync)
+	m.bus.subscribe("order_watcher", m.ow.Messages, owConfig, nil)
+	m.private.subscribe("private_orders", m.ow.PrivateMessages, privateConfig, nil)
+	//if defaultConfig.Verify {
+	//	m.bus.subscribe("verify", m.verify.Messages, ConsumerConfig{Policy: string(policyDropOldest)}, nil)
+	//}
+
+	return m
+}
+
+//subscribe registers another consumer of the events, e.g. a recorder, it receives the events dispatched from now on
+func (m *market) subscribe(name string, events chan *stream.Event, config ConsumerConfig) {
+	m.bus.subscribe(name, events, config, nil)
+}
+
+func (m *market) run() {
+	//init ob
+	go m.ob.ReloadOrderBook()
+
+	go m.ow.Run()
+	if defaultConfig.PrivateOrders {
+		go m.ow.RunPrivate()
+	}
+
+	//if defaultConfig.Verify {
+	//	go m.verify.Run()
+	//}
+}
+
+//stop closes the message channels, so the builder, the watcher and the other consumers quit,
+//the dispatches blocked on a full channel return first
+func (m *market) stop() {
+	m.bus.close()
+	m.private.close()
+}
+
+func (m *market) status() *exchanges.SymbolStatus {
+	state, sequence := m.ob.Status()
+	sizeUnit, multiplier := m.ob.SizeUnit()
+	return &exchanges.SymbolStatus{
+		Symbol:    m.symbol,
+		State:     state.String(),
+		Sequence:  sequence,
+		SyncStats: m.ob.SyncStats(),
+		Consumers: append(m.bus.stats(), m.private.stats()...),
+
+		SizeUnit:   sizeUnit,
+		Multiplier: multiplier,
+
+		LastMessageTime: atomic.LoadInt64(&m.lastMessageTime),
+	}
+}
+
+//dispatch decodes the message delivered by the connection once and publishes the event to the consumers,
+//unless another connection has delivered the same sequence, which is dropped before decoding,
+//the sequence is marked only once decoded, so a copy failing to decode does not drop the copy of another connection
+func (m *market) dispatch(connId int, msgRawData *sdk.WebSocketDownstreamMessage) {
+	if sequence, ok := stream.PeekSequence(msgRawData.RawData); ok && m.duplicate(connId, sequence) {
+		return
+	}
+
+	event, err := m.bus.decode(msgRawData)
+	if err != nil {
+		log.Error("NewEvent err", zap.String("symbol", m.symbol), zap.String("Data", string(msgRawData.RawData)), zap.Error(err))
+		return
+	}
+
+	m.dispatchLock.Lock()
+	defer m.dispatchLock.Unlock()
+
+	//the copy of another connection may be decoded meanwhile
+	if !m.first(connId, event.Sequence) {
+		return
+	}
+
+	atomic.StoreInt64(&m.lastMessageTime, time.Now().UnixNano())
+	m.bus.publish(event)
+}
+
+//duplicate reports whether another connection has delivered the sequence and counts it, without marking the sequence
+func (m *market) duplicate(connId int, sequence uint64) bool {
+	m.dispatchLock.Lock()
+	defer m.dispatchLock.Unlock()
+
+	if len(m.firsts) > 1 && m.filter.passed(sequence) {
+		atomic.AddUint64(&m.duplicates[connId], 1)
+		return true
+	}
+
+	return false
+}
+
+//first reports whether the connection delivers the sequence first and counts it, the caller must hold dispatchLock
+func (m *market) first(connId int, sequence uint64) bool {
+	if len(m.firsts) > 1 && !m.filter.first(sequence) {
+		atomic.AddUint64(&m.duplicates[connId], 1)
+		return false
+	}
+
+	atomic.AddUint64(&m.firsts[connId], 1)
+	return true
+}
+
+//dispatchPrivate publishes the decoded private order change to the OrderWatcher, it is dropped once the market is stopped
+func (m *market) dispatchPrivate(event *stream.Event) {
+	m.dispatchLock.Lock()
+	defer m.dispatchLock.Unlock()
+
+	m.private.publish(event)
+}
+
+const sequenceFilterSize = 1 << 12
+
+//sequenceFilter remembers the latest sequences to drop the duplicates of the redundant connections
+type sequenceFilter struct {
+	seen []uint64
+	max  uint64 //the highest sequence passed
+}
+
+func newSequenceFilter() *sequenceFilter {
+	return &sequenceFilter{
+		seen: make([]uint64, sequenceFilterSize),
+	}
+}
+
+//first reports whether the sequence arrives for the first time and marks it,
+//0 is no sequence and always passes
+func (f *sequenceFilter) first(sequence uint64) bool {
+	if sequence == 0 {
+		return true
+	}
+	if f.passed(sequence) {
+		return false
+	}
+
+	f.seen[sequence%sequenceFilterSize] = sequence
+	if sequence > f.max {
+		f.max = sequence
+	}
+	return true
+}
+
+//passed reports whether the sequence passed already,
+//a sequence older than the remembered ones is taken as passed, as its slot may hold a newer sequence already
+func (f *sequenceFilter) passed(sequence uint64) bool {
+	if sequence == 0 {
+		return false
+	}
+	if f.max >= sequenceFilterSize && sequence <= f.max-sequenceFilterSize {
+		return true
+	}
+
+	return f.seen[sequence%sequenceFilterSize] >= sequence
+}
+
+//registry indexes markets by symbol and by websocket topic
+type registry struct {
+	lock    *sync.RWMutex
+	symbols map[string]*market
+	topics  map[string]*market
+}
+
+func newRegistry() *registry {
+	return &registry{
+		lock:    &sync.RWMutex{},
+		symbols: make(map[string]*market),
+		topics:  make(map[string]*market),
+	}
+}
+
+func (r *registry) add(m *market) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if _, ok := r.symbols[m.symbol]; ok {
+		return fmt.Errorf("symbol %s exists already", m.symbol)
+	}
+
+	r.symbols[m.symbol] = m
+	r.topics[m.topic] = m
+	return nil
+}
+
+func (r *registry) remove(symbol string) (*market, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	m, ok := r.symbols[symbol]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", exchanges.ErrSymbolNotFound, symbol)
+	}
+
+	delete(r.symbols, m.symbol)
+	delete(r.topics, m.topic)
+	return m, nil
+}
+
+func (r *registry) get(symbol string) (*market, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	m, ok := r.symbols[symbol]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", exchanges.ErrSymbolNotFound, symbol)
+	}
+
+	return m, nil
+}
+
+//dispatch sends the message to the market of its topic, the send may block on a full channel,
+//so it is done after releasing the lock, a market removed meanwhile drops the message
+func (r *registry) dispatch(connId int, msgRawData *sdk.WebSocketDownstreamMessage) bool {
+	r.lock.RLock()
+	m, ok := r.topics[msgRawData.Topic]
+	r.lock.RUnlock()
+	if !ok {
+		return false
+	}
+
+	m.dispatch(connId, msgRawData)
+	return true
+}
+
+//dispatchPrivate sends the private order change to the OrderWatcher of the symbol, after releasing the lock
+func (r *registry) dispatchPrivate(symbol string, event *stream.Event) bool {
+	r.lock.RLock()
+	m, ok := r.symbols[symbol]
+	r.lock.RUnlock()
+	if !ok {
+		return false
+	}
+
+	m.dispatchPrivate(event)
+	return true
+}
+
+func (r *registry) all() []*market {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	markets := make([]*market, 0, len(r.symbols))
+	for _, m := range r.symbols {
+		markets = append(markets, m)
+	}
+	sort.Slice(markets, func(i, j int) bool {
+		return markets[i].symbol < markets[j].symbol
+	})
+
+	return markets
+}
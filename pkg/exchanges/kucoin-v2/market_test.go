@@ -0,0 +1,99 @@
+package kucoin_v2
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/sdk"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/stream"
+)
+
+func TestSequenceFilter(t *testing.T) {
+	f := newSequenceFilter()
+	if !f.first(1) || f.first(1) {
+		t.Error("the duplicate should be dropped")
+	}
+	if !f.first(0) || !f.first(0) {
+		t.Error("0 is no sequence and should always pass")
+	}
+
+	//the slot of 1 is taken by the sequence a window later
+	if !f.first(1+sequenceFilterSize) || f.first(1+sequenceFilterSize) {
+		t.Error("the duplicate of the wrapped sequence should be dropped")
+	}
+	if f.first(1) {
+		t.Error("the late duplicate of the overwritten slot should be dropped")
+	}
+	if !f.first(2) || f.first(2) {
+		t.Error("the sequences within the window should pass once")
+	}
+
+	//a sequence behind the window is dropped even if its slot is free
+	if !f.first(3 * sequenceFilterSize) {
+		t.Fatal("a new sequence should pass")
+	}
+	if f.first(sequenceFilterSize + 5) {
+		t.Error("the sequence behind the window should be dropped")
+	}
+}
+
+func TestDispatchDuplicates(t *testing.T) {
+	defer func(connections int) { defaultConfig.Connections = connections }(defaultConfig.Connections)
+	defaultConfig.Connections = 2
+
+	ex := newTestExchange(newFakeClient(), newFakeClient())
+	m := newMarket(nil, "KCS-USDT")
+	if err := ex.markets.add(m); err != nil {
+		t.Fatal(err)
+	}
+	dispatch := func(connId int, sequence uint64) {
+		raw, _ := json.Marshal(map[string]interface{}{"sequence": sequence, "orderId": "a", "ts": sequence})
+		ex.dispatch(ex.conns[connId], &sdk.WebSocketDownstreamMessage{Topic: m.topic, Subject: stream.MessageDoneType, RawData: raw})
+	}
+
+	dispatch(0, 1)
+	dispatch(1, 1)
+	dispatch(1, 2)
+	dispatch(0, 2)
+	dispatch(0, 0)
+	dispatch(1, 0)
+	if len(m.ob.Messages) != 4 {
+		t.Errorf("the first arrivals and the messages without sequence should be published, got %d", len(m.ob.Messages))
+	}
+	for connId, want := range [][2]uint64{{2, 1}, {2, 1}} {
+		if m.firsts[connId] != want[0] || m.duplicates[connId] != want[1] {
+			t.Errorf("connection %d: firsts %d, duplicates %d, want %v", connId, m.firsts[connId], m.duplicates[connId], want)
+		}
+	}
+
+	for _, want := range []uint64{1, 2, 0, 0} {
+		if event := <-m.ob.Messages; event.Sequence != want {
+			t.Errorf("want sequence %d, got %d", want, event.Sequence)
+		}
+	}
+}
+
+//TestDispatchUndecodableCopy checks a copy failing to decode does not drop the copy of another connection
+func TestDispatchUndecodableCopy(t *testing.T) {
+	defer func(connections int) { defaultConfig.Connections = connections }(defaultConfig.Connections)
+	defaultConfig.Connections = 2
+
+	ex := newTestExchange(newFakeClient(), newFakeClient())
+	m := newMarket(nil, "KCS-USDT")
+	if err := ex.markets.add(m); err != nil {
+		t.Fatal(err)
+	}
+
+	//the orderId of the broken copy is not a string
+	broken := []byte(`{"sequence": 1, "orderId": 1, "ts": 1}`)
+	ex.dispatch(ex.conns[0], &sdk.WebSocketDownstreamMessage{Topic: m.topic, Subject: stream.MessageDoneType, RawData: broken})
+	raw, _ := json.Marshal(map[string]interface{}{"sequence": 1, "orderId": "a", "ts": 1})
+	ex.dispatch(ex.conns[1], &sdk.WebSocketDownstreamMessage{Topic: m.topic, Subject: stream.MessageDoneType, RawData: raw})
+
+	if len(m.ob.Messages) != 1 {
+		t.Fatalf("the valid copy should be published, got %d messages", len(m.ob.Messages))
+	}
+	if event := <-m.ob.Messages; event.Sequence != 1 || m.firsts[1] != 1 || m.duplicates[1] != 0 {
+		t.Errorf("unexpected sequence %d, firsts %d, duplicates %d", event.Sequence, m.firsts[1], m.duplicates[1])
+	}
+}
@@ -0,0 +1,138 @@
+package orderbook
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/sdk"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/stream"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/level3"
+)
+
+//busyStreamFile is a spot stream of a busy pair after sequence 1, in the form of the websocket messages one per line,
+//bids are below 1000 and asks above, so the book never crosses
+const busyStreamFile = "testdata/busy_stream.jsonl.gz"
+
+//readBusyStream returns the first n messages of busyStreamFile
+func readBusyStream(tb testing.TB, n int) []*stream.Event {
+	f, err := os.Open(busyStreamFile)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	messages := make([]*stream.Event, 0, n)
+	scanner := bufio.NewScanner(zr)
+	for len(messages) < n && scanner.Scan() {
+		msg := &sdk.WebSocketDownstreamMessage{}
+		if err := json.Unmarshal(scanner.Bytes(), msg); err != nil {
+			tb.Fatal(err)
+		}
+		event, err := stream.NewEvent(msg, false)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		messages = append(messages, event)
+	}
+	if err := scanner.Err(); err != nil {
+		tb.Fatal(err)
+	}
+	if len(messages) < n {
+		tb.Fatalf("%s has %d messages, %d wanted", busyStreamFile, len(messages), n)
+	}
+
+	return messages
+}
+
+func newBusyBuilder(tb testing.TB, messages []*stream.Event) *Builder {
+	builder := NewBuilder(nil, "KCS-USDT", Options{PriceIncrement: "0.01", SizeIncrement: "0.0001"})
+	builder.resetOrderBook()
+	builder.AddDepthToOrderBook(&DepthResponse{Sequence: 1})
+	for _, msg := range messages {
+		if err := builder.updateFromStream(msg); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	builder.setState(StateLive)
+	return builder
+}
+
+//TestBusyStreamOutput checks the output is byte-identical to the output of the decimal.Decimal order book,
+//the golden files were written by the builder before prices and sizes became int64, over the same busyStreamFile
+func TestBusyStreamOutput(t *testing.T) {
+	builder := newBusyBuilder(t, readBusyStream(t, 5000))
+
+	l3, err := builder.SnapshotBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	l2, err := builder.GetPartOrderBook(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l2Bytes, err := json.Marshal(map[string]interface{}{"asks": l2.Asks, "bids": l2.Bids})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, got := range map[string][]byte{
+		"testdata/busy_stream_l3.json": l3,
+		"testdata/busy_stream_l2.json": l2Bytes,
+	} {
+		want, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(append(got, '\n'), want) {
+			t.Errorf("output differs from %s", name)
+		}
+	}
+}
+
+//TestBusyStreamBinary checks the binary snapshot decodes to the JSON snapshot of the same order book
+func TestBusyStreamBinary(t *testing.T) {
+	builder := newBusyBuilder(t, readBusyStream(t, 5000))
+
+	snapshot, err := builder.GetL3BinarySnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ob, bookTime, err := level3.DecodeBinary(bytes.NewReader(snapshot.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bookTime != snapshot.LastUpdateTime || bookTime == 0 || ob.Sequence != snapshot.Sequence {
+		t.Errorf("unexpected book time %d, sequence %d", bookTime, ob.Sequence)
+	}
+
+	got, err := json.Marshal(ob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := builder.SnapshotBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("the decoded binary snapshot differs from the JSON snapshot")
+	}
+	t.Logf("binary: %d bytes, json: %d bytes", len(snapshot.Data), len(want))
+}
+
+func BenchmarkUpdateFromStream(b *testing.B) {
+	messages := readBusyStream(b, 5000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		newBusyBuilder(b, messages)
+	}
+	b.ReportMetric(float64(b.N*len(messages))/b.Elapsed().Seconds(), "msgs/s")
+}
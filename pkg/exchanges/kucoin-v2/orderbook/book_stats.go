@@ -0,0 +1,188 @@
+package orderbook
+
+import (
+	"sort"
+
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/base"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/fixed"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/level3"
+	"github.com/shopspring/decimal"
+)
+
+//bookStats keeps the market statistics of the order book, they are walked again only
+//when the order book changes within the levels walked last time, so reading them is O(1),
+//a copy published with the view of the order book is never changed
+type bookStats struct {
+	depth int
+	bands []int //bps, from narrow to wide
+
+	book       *level3.OrderBook //the order book walked last time
+	priceScale fixed.Scale
+	sizeScale  fixed.Scale
+	mid2       int64 //best ask + best bid, the doubled mid price
+	ask        sideStats
+	bid        sideStats
+}
+
+type sideStats struct {
+	ok       bool //the side is not empty
+	best     int64
+	bestSize int64
+	volume   int64   //size of the best depth levels
+	limits   []int64 //the farthest price of each band
+	bands    []int64 //cumulative size of each band
+
+	//the walk stopped at the level of window, the levels beyond it do not affect the stats,
+	//unbounded when all levels are walked
+	window  int64
+	bounded bool
+}
+
+func newBookStats(depth int, bands []int) *bookStats {
+	bands = append([]int{}, bands...)
+	sort.Ints(bands)
+
+	return &bookStats{
+		depth: depth,
+		bands: bands,
+	}
+}
+
+//refresh walks the view of the order book again if the order book is changed within the levels walked last time,
+//it reports whether the stats are walked again, the caller resets the changes of the order book afterwards
+func (s *bookStats) refresh(ob *level3.OrderBook, book *level3.View) bool {
+	if s.book == ob && !changedWithin(ob, base.AskSide, s.ask.window, s.ask.bounded) && !changedWithin(ob, base.BidSide, s.bid.window, s.bid.bounded) {
+		return false
+	}
+	s.book = ob
+	s.priceScale, s.sizeScale = book.PriceScale, book.SizeScale
+
+	ask, bid := bestLevel(book, base.AskSide), bestLevel(book, base.BidSide)
+	s.mid2 = 0
+	s.ask.limits, s.bid.limits = nil, nil
+	if ask != nil && bid != nil {
+		s.mid2 = ask.Price + bid.Price
+		for _, bps := range s.bands {
+			s.ask.limits = append(s.ask.limits, mulDiv(s.mid2, int64(10000+bps), 20000, false))
+			s.bid.limits = append(s.bid.limits, mulDiv(s.mid2, int64(10000-bps), 20000, true))
+		}
+	}
+
+	s.ask.walk(book, base.AskSide, s.depth)
+	s.bid.walk(book, base.BidSide, s.depth)
+	return true
+}
+
+//mulDiv returns v * mul / div of a non-negative v without overflowing v * mul, rounded down or up
+func mulDiv(v, mul, div int64, roundUp bool) int64 {
+	q, r := v/div, v%div
+	ret := q*mul + r*mul/div
+	if roundUp && r*mul%div != 0 {
+		ret++
+	}
+
+	return ret
+}
+
+func bestLevel(book *level3.View, side string) (best *level3.LevelView) {
+	book.RangeLevels(side, func(level *level3.LevelView) bool {
+		best = level
+		return false
+	})
+	return
+}
+
+func (s *sideStats) walk(book *level3.View, side string, depth int) {
+	s.ok, s.volume, s.bounded = false, 0, false
+	s.bands = make([]int64, len(s.limits))
+
+	count := 0
+	book.RangeLevels(side, func(level *level3.LevelView) bool {
+		if count == 0 {
+			s.ok, s.best, s.bestSize = true, level.Price, level.Size
+		}
+
+		inBand := false
+		for i, limit := range s.limits {
+			if side == base.AskSide && level.Price <= limit || side == base.BidSide && level.Price >= limit {
+				s.bands[i] += level.Size
+				inBand = true
+			}
+		}
+
+		if count >= depth && !inBand {
+			s.window, s.bounded = level.Price, true
+			return false
+		}
+
+		if count < depth {
+			s.volume += level.Size
+		}
+		count++
+		return true
+	})
+}
+
+func (s *bookStats) format(priceTick int64) *exchanges.BookStats {
+	priceDecimals := s.priceScale.Decimals
+	sizeDecimals := s.sizeScale.Decimals
+
+	data := &exchanges.BookStats{
+		Depth:     s.depth,
+		AskVolume: s.sizeScale.Format(s.ask.volume),
+		BidVolume: s.sizeScale.Format(s.bid.volume),
+	}
+
+	if s.ask.ok {
+		data.BestAsk = s.priceScale.Format(s.ask.best)
+		data.BestAskSize = s.sizeScale.Format(s.ask.bestSize)
+	}
+	if s.bid.ok {
+		data.BestBid = s.priceScale.Format(s.bid.best)
+		data.BestBidSize = s.sizeScale.Format(s.bid.bestSize)
+	}
+
+	if total := s.ask.volume + s.bid.volume; total > 0 {
+		data.Imbalance = decimal.New(s.bid.volume-s.ask.volume, 0).DivRound(decimal.New(total, 0), 4).String()
+	}
+
+	if !s.ask.ok || !s.bid.ok {
+		return data
+	}
+
+	spread := s.ask.best - s.bid.best
+	mid := decimal.New(s.mid2, -priceDecimals).Div(decimal.New(2, 0))
+	data.Spread = s.priceScale.Format(spread)
+	if priceTick > 0 {
+		data.SpreadTicks = spread / priceTick
+	}
+	data.SpreadBps = decimal.New(spread, -priceDecimals).Div(mid).Mul(decimal.New(10000, 0)).Round(2).String()
+	data.Mid = mid.String()
+
+	askPrice, bidPrice := decimal.New(s.ask.best, -priceDecimals), decimal.New(s.bid.best, -priceDecimals)
+	askSize, bidSize := decimal.New(s.ask.bestSize, -sizeDecimals), decimal.New(s.bid.bestSize, -sizeDecimals)
+	data.Microprice = bidPrice.Mul(askSize).Add(askPrice.Mul(bidSize)).DivRound(askSize.Add(bidSize), priceDecimals+impactDecimals).String()
+
+	data.Bands = make([]*exchanges.DepthBand, 0, len(s.bands))
+	for i, bps := range s.bands {
+		data.Bands = append(data.Bands, &exchanges.DepthBand{
+			Bps:     bps,
+			AskSize: s.sizeScale.Format(s.ask.bands[i]),
+			BidSize: s.sizeScale.Format(s.bid.bands[i]),
+		})
+	}
+
+	return data
+}
+
+//GetBookStats returns the market statistics of the order book,
+//along with an error wrapping exchanges.ErrOrderBookNotLive unless the order book is live
+func (b *Builder) GetBookStats() (*exchanges.BookStats, error) {
+	view := b.loadView()
+	data := view.stats.format(view.priceTick)
+	data.State = view.state.String()
+	data.Sequence = view.sequence
+
+	return data, view.checkLive(b.symbol)
+}
@@ -0,0 +1,78 @@
+package orderbook
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/base"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/fixed"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/level3"
+)
+
+func TestBookStats(t *testing.T) {
+	price, _ := fixed.NewScale(2)
+	size, _ := fixed.NewScale(4)
+	ob := level3.NewOrderBook(price, size)
+	for i, o := range [][3]string{
+		{base.AskSide, "10.1", "1"},
+		{base.AskSide, "10.15", "3"},
+		{base.AskSide, "10.5", "5"},
+		{base.BidSide, "9.9", "3"},
+		{base.BidSide, "9.5", "2"},
+	} {
+		order, err := ob.ParseOrder(string(rune('a'+i)), o[0], o[1], o[2], uint64(i), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ob.AddOrder(order); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats := newBookStats(2, []int{200, 100})
+	stats.refresh(ob, ob.View())
+	want := &exchanges.BookStats{
+		BestAsk:     "10.1",
+		BestAskSize: "1",
+		BestBid:     "9.9",
+		BestBidSize: "3",
+		Spread:      "0.2",
+		SpreadTicks: 4,
+		SpreadBps:   "200",
+		Mid:         "10",
+		Microprice:  "10.05",
+		Depth:       2,
+		AskVolume:   "4",
+		BidVolume:   "5",
+		Imbalance:   "0.1111",
+		Bands: []*exchanges.DepthBand{
+			{Bps: 100, AskSize: "1", BidSize: "3"},
+			{Bps: 200, AskSize: "4", BidSize: "3"},
+		},
+	}
+	if got := stats.format(5); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+//TestBookStatsRefresh checks the stats skipping the changes beyond the levels walked
+//are the same as the stats walked from scratch after every message
+func TestBookStatsRefresh(t *testing.T) {
+	messages := readBusyStream(t, 5000)
+	b := NewBuilder(nil, "KCS-USDT", Options{PriceIncrement: "0.01", SizeIncrement: "0.0001", StatsDepth: 5, StatsBands: []int{10, 100}})
+	b.resetOrderBook()
+	b.AddDepthToOrderBook(&DepthResponse{Sequence: 1})
+
+	for _, msg := range messages {
+		if err := b.updateFromStream(msg); err != nil {
+			t.Fatal(err)
+		}
+
+		fresh := newBookStats(5, []int{10, 100})
+		fresh.refresh(b.fullOrderBook, b.fullOrderBook.View())
+		if got, want := b.loadView().stats.format(1), fresh.format(1); !reflect.DeepEqual(got, want) {
+			t.Fatalf("sequence %d: got %+v, want %+v", msg.Sequence, got, want)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package orderbook
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/stream"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/services/log"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/base"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/level3"
+	"go.uber.org/zap"
+)
+
+//Checkpoint is the full order book at a sequence, in the form of a snapshot of the exchange
+//with the sequence which opened each order, the orders of each price level are in the order of the queue
+type Checkpoint struct {
+	Symbol         string `json:"symbol"`
+	PriceIncrement string `json:"priceIncrement"`
+	SizeIncrement  string `json:"sizeIncrement"`
+	Multiplier     string `json:"multiplier,omitempty"`
+	OrderBookTime  uint64 `json:"orderBookTime"`
+	Sequence       uint64 `json:"sequence"`
+	//[5]interface{}{"orderId", "price", "size", "time", "sequence"}, the sequence is missing in older checkpoints
+	Asks [][5]interface{} `json:"asks"`
+	Bids [][5]interface{} `json:"bids"`
+}
+
+func newCheckpoint(symbol, priceIncrement, sizeIncrement string, orderBookTime uint64, ob *level3.View) *Checkpoint {
+	checkpoint := &Checkpoint{
+		Symbol:         symbol,
+		PriceIncrement: priceIncrement,
+		SizeIncrement:  sizeIncrement,
+		OrderBookTime:  orderBookTime,
+		Sequence:       ob.Sequence,
+		Asks:           [][5]interface{}{},
+		Bids:           [][5]interface{}{},
+	}
+
+	for _, side := range []string{base.AskSide, base.BidSide} {
+		elems := &checkpoint.Asks
+		if side == base.BidSide {
+			elems = &checkpoint.Bids
+		}
+
+		ob.RangeLevels(side, func(level *level3.LevelView) bool {
+			level.RangeOrders(func(order *level3.Order) bool {
+				*elems = append(*elems, [5]interface{}{order.OrderId, ob.FormatPrice(order.Price), ob.FormatSize(order.Size), order.Time, order.Sequence})
+				return true
+			})
+			return true
+		})
+	}
+
+	return checkpoint
+}
+
+func (b *Builder) checkpoints() bool {
+	return b.checkpointDir != "" && b.checkpointInterval > 0
+}
+
+func (b *Builder) checkpointPath() string {
+	return filepath.Join(b.checkpointDir, b.symbol+".json")
+}
+
+//writeCheckpoint replaces the checkpoint file atomically, a crash leaves the previous checkpoint intact
+func writeCheckpoint(path string, checkpoint *Checkpoint) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	w := bufio.NewWriter(f)
+	if err := json.NewEncoder(w).Encode(checkpoint); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+func readCheckpoint(path string) (*Checkpoint, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	//decode numbers as the http client does for the snapshots
+	decoder := json.NewDecoder(bufio.NewReader(f))
+	decoder.UseNumber()
+	checkpoint := &Checkpoint{}
+	if err := decoder.Decode(checkpoint); err != nil {
+		return nil, err
+	}
+
+	return checkpoint, nil
+}
+
+//runCheckpoints writes a checkpoint of the live order book every checkpointInterval until done is closed
+func (b *Builder) runCheckpoints(done chan struct{}) {
+	ticker := time.NewTicker(b.checkpointInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := b.WriteCheckpoint(); err != nil {
+				log.Error("write checkpoint error, symbol: "+b.symbol, zap.Error(err))
+			}
+		}
+	}
+}
+
+//WriteCheckpoint writes a checkpoint of the order book if it is live,
+//the published view of the full order book is encoded without blocking the writer
+func (b *Builder) WriteCheckpoint() error {
+	view := b.loadView()
+	if view.state != StateLive {
+		return nil
+	}
+
+	start := time.Now()
+	checkpoint := newCheckpoint(b.symbol, view.priceIncrement, view.sizeIncrement, view.orderBookTime, view.book)
+	checkpoint.Multiplier = view.multiplier
+	if err := writeCheckpoint(b.checkpointPath(), checkpoint); err != nil {
+		return err
+	}
+
+	log.Debug(fmt.Sprintf("write checkpoint, symbol: %s, sequence: %d", b.symbol, checkpoint.Sequence), zap.Duration("elapsed", time.Since(start)))
+	return nil
+}
+
+//loadCheckpoint replaces the order book by the checkpoint of the symbol
+func (b *Builder) loadCheckpoint() error {
+	checkpoint, err := readCheckpoint(b.checkpointPath())
+	if err != nil {
+		return err
+	}
+	if checkpoint.Symbol != b.symbol {
+		return errors.New("checkpoint of another symbol: " + checkpoint.Symbol)
+	}
+
+	if !b.scaled {
+		if err := b.setScales(checkpoint.PriceIncrement, checkpoint.SizeIncrement, checkpoint.Multiplier); err != nil {
+			return err
+		}
+	}
+
+	//the checkpoint of an order book in wider scales than the increments
+	priceScale, sizeScale := b.priceScale, b.sizeScale
+	for _, elems := range [][][5]interface{}{checkpoint.Asks, checkpoint.Bids} {
+		for _, elem := range elems {
+			price, _ := elem[1].(string)
+			size, _ := elem[2].(string)
+			if priceScale, sizeScale, err = widenScales(priceScale, sizeScale, price, size); err != nil {
+				return err
+			}
+		}
+	}
+
+	fullOrderBook := level3.NewOrderBook(priceScale, sizeScale)
+	fullOrderBook.Sequence = checkpoint.Sequence
+	for _, side := range []string{base.AskSide, base.BidSide} {
+		elems := checkpoint.Asks
+		if side == base.BidSide {
+			elems = checkpoint.Bids
+		}
+
+		for _, elem := range elems {
+			order, err := checkpointOrder(fullOrderBook, side, elem)
+			if err != nil {
+				return err
+			}
+			if err := fullOrderBook.AppendOrder(order); err != nil {
+				return err
+			}
+		}
+	}
+
+	b.resetOrderBook()
+	b.lock.Lock()
+	if err := b.setWiderScales(priceScale, sizeScale); err != nil {
+		b.lock.Unlock()
+		return err
+	}
+	b.fullOrderBook = fullOrderBook
+	b.Sequence = checkpoint.Sequence
+	b.OrderBookTime = checkpoint.OrderBookTime
+	b.publish()
+	b.lock.Unlock()
+
+	return nil
+}
+
+//checkpointOrder parses an order of the checkpoint, the sequence is 0 when the checkpoint has none
+func checkpointOrder(fullOrderBook *level3.OrderBook, side string, elem [5]interface{}) (*level3.Order, error) {
+	_, idOk := elem[0].(string)
+	_, timeOk := elem[3].(json.Number)
+	if !idOk || !timeOk {
+		return nil, fmt.Errorf("error checkpoint order: %v", elem)
+	}
+	order, err := newOrderWithElem(fullOrderBook, side, [4]interface{}{elem[0], elem[1], elem[2], elem[3]}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if elem[4] != nil {
+		sequence, ok := elem[4].(json.Number)
+		if !ok {
+			return nil, fmt.Errorf("error checkpoint order sequence: %v", elem)
+		}
+		if order.Sequence, err = strconv.ParseUint(sequence.String(), 10, 64); err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
+
+//warmStart loads the checkpoint and follows the stream from it if the stream continues its sequence,
+//otherwise it returns the message to playback from a snapshot, ok is false when the message channel is closed
+func (b *Builder) warmStart() (first *stream.Event, ok bool) {
+	if !b.checkpoints() {
+		return nil, true
+	}
+
+	if err := b.loadCheckpoint(); err != nil {
+		if !os.IsNotExist(err) {
+			log.Warn("load checkpoint failed, playback from a snapshot, symbol: "+b.symbol, zap.Error(err))
+		}
+		return nil, true
+	}
+	checkpointSequence := b.Sequence
+	log.Info(fmt.Sprintf("loaded checkpoint, symbol: %s, sequence: %d, bridging...", b.symbol, checkpointSequence))
+	b.setState(StatePlayback)
+
+	//the reorder buffer holds the messages after a gap, the checkpoint is bridged once the next sequence arrives,
+	//or abandoned when the gap expires, a gap wider than the reorder window is abandoned at once
+	for b.Sequence == checkpointSequence {
+		msg, ok := b.nextMessage()
+		if !ok {
+			return nil, false
+		}
+
+		if atomic.LoadUint32(&b.resync) == 1 {
+			return msg, true
+		}
+
+		if msg != nil && msg.Sequence > checkpointSequence+1 && msg.Sequence-checkpointSequence-1 > uint64(b.reorder.window) {
+			log.Info(fmt.Sprintf("checkpoint is not bridged, the gap is wider than the reorder window, playback from a snapshot, symbol: %s, sequence: %d => %d", b.symbol, checkpointSequence, msg.Sequence))
+			return msg, true
+		}
+
+		if err := b.applyMessage(msg); err != nil {
+			log.Info("checkpoint is not bridged, playback from a snapshot, symbol: "+b.symbol, zap.Error(err))
+			return msg, true
+		}
+	}
+
+	log.Info(fmt.Sprintf("checkpoint bridged, symbol: %s, sequence: %d => %d", b.symbol, checkpointSequence, b.Sequence))
+	b.setState(StateLive)
+
+	next, ok := b.follow()
+	if ok {
+		b.countResync()
+	}
+	return next, ok
+}
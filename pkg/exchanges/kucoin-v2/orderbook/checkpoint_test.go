@@ -0,0 +1,162 @@
+package orderbook
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/stream"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/base"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/level3"
+)
+
+func TestCheckpointWarmStart(t *testing.T) {
+	dir := t.TempDir()
+	messages := readBusyStream(t, 3000)
+
+	src := newBusyBuilder(t, messages[:2000])
+	src.checkpointDir, src.checkpointInterval = dir, time.Minute
+	if err := src.WriteCheckpoint(); err != nil {
+		t.Fatal(err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 1 || files[0] != src.checkpointPath() {
+		t.Fatalf("the checkpoint should be renamed into place, got %v", files)
+	}
+
+	//the stream overlaps the checkpoint, the messages up to its sequence are skipped
+	b := NewBuilder(nil, "KCS-USDT", Options{ReorderWindow: 10, ReorderTimeout: time.Minute, CheckpointDir: dir, CheckpointInterval: time.Minute})
+	for _, msg := range messages[1990:] {
+		b.Messages <- msg
+	}
+	close(b.Messages)
+	b.ReloadOrderBook()
+
+	if state, sequence := b.Status(); state != StateLive || sequence != 3001 || b.SyncStats().Resyncs != 0 {
+		t.Fatalf("the checkpoint should be bridged, state: %s, sequence: %d, resyncs: %d", state, sequence, b.SyncStats().Resyncs)
+	}
+	got, err := b.SnapshotBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := newBusyBuilder(t, messages).SnapshotBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("the order book bridged from the checkpoint differs from the order book of the whole stream")
+	}
+	if got, want := orderSequences(b), orderSequences(newBusyBuilder(t, messages)); !reflect.DeepEqual(got, want) {
+		t.Errorf("the orders should keep the sequences which opened them")
+	}
+}
+
+//orderSequences returns the sequence of each order, the queue priority among orders of the same time
+func orderSequences(b *Builder) map[string]uint64 {
+	sequences := map[string]uint64{}
+	for _, side := range []string{base.AskSide, base.BidSide} {
+		b.loadView().book.RangeLevels(side, func(level *level3.LevelView) bool {
+			level.RangeOrders(func(order *level3.Order) bool {
+				sequences[order.OrderId] = order.Sequence
+				return true
+			})
+			return true
+		})
+	}
+	return sequences
+}
+
+//TestCheckpointLargeGap checks a gap wider than the reorder window falls back to a snapshot without waiting for the timeout,
+//and a gap within the window is bridged
+func TestCheckpointLargeGap(t *testing.T) {
+	dir := t.TempDir()
+	messages := readBusyStream(t, 200)
+
+	src := newBusyBuilder(t, messages[:100])
+	src.checkpointDir, src.checkpointInterval = dir, time.Minute
+	if err := src.WriteCheckpoint(); err != nil {
+		t.Fatal(err)
+	}
+
+	warmStart := func(b *Builder) *stream.Event {
+		t.Helper()
+		done := make(chan *stream.Event, 1)
+		go func() {
+			first, _ := b.warmStart()
+			done <- first
+		}()
+		select {
+		case first := <-done:
+			return first
+		case <-time.After(time.Second):
+			t.Fatal("the warm start should not wait for the reorder timeout")
+		}
+		return nil
+	}
+
+	//the first 50 sequences after the checkpoint were missed while stopped
+	b := NewBuilder(nil, "KCS-USDT", Options{ReorderWindow: 10, ReorderTimeout: time.Hour, CheckpointDir: dir, CheckpointInterval: time.Minute})
+	b.Messages <- messages[150]
+	if first := warmStart(b); first != messages[150] {
+		t.Errorf("the message after the gap should be returned to playback from, got %v", first)
+	}
+
+	//the next 5 sequences arrive late, after the ones behind them
+	b = NewBuilder(nil, "KCS-USDT", Options{ReorderWindow: 10, ReorderTimeout: time.Hour, CheckpointDir: dir, CheckpointInterval: time.Minute})
+	for _, msg := range append(messages[105:110:110], messages[100:105]...) {
+		b.Messages <- msg
+	}
+	close(b.Messages)
+	if first := warmStart(b); first != nil {
+		t.Errorf("the stream should end after the bridge, got %v", first)
+	}
+	if state, sequence := b.Status(); state != StateLive || sequence != 111 {
+		t.Errorf("the checkpoint should be bridged, state: %s, sequence: %d", state, sequence)
+	}
+}
+
+func TestCheckpointWithoutSequences(t *testing.T) {
+	dir := t.TempDir()
+	data := `{"symbol":"KCS-USDT","priceIncrement":"0.01","sizeIncrement":"0.0001","orderBookTime":1,"sequence":5,` +
+		`"asks":[["a1","1.5","2",10]],"bids":[["b1","1.4","1",11,3]]}`
+	if err := os.WriteFile(filepath.Join(dir, "KCS-USDT.json"), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	b := NewBuilder(nil, "KCS-USDT", Options{CheckpointDir: dir, CheckpointInterval: time.Minute})
+	if err := b.loadCheckpoint(); err != nil {
+		t.Fatal(err)
+	}
+	if got := orderSequences(b); b.Sequence != 5 || !reflect.DeepEqual(got, map[string]uint64{"a1": 0, "b1": 3}) {
+		t.Errorf("sequence: %d, order sequences: %v", b.Sequence, got)
+	}
+}
+
+func TestCheckpointNotBridged(t *testing.T) {
+	dir := t.TempDir()
+	messages := readBusyStream(t, 200)
+
+	src := newBusyBuilder(t, messages[:100])
+	src.checkpointDir, src.checkpointInterval = dir, time.Minute
+	if err := src.WriteCheckpoint(); err != nil {
+		t.Fatal(err)
+	}
+
+	//the messages after the checkpoint were missed while stopped
+	b := NewBuilder(nil, "KCS-USDT", Options{CheckpointDir: dir, CheckpointInterval: time.Minute})
+	b.Messages <- messages[150]
+	first, ok := b.warmStart()
+	if !ok || first != messages[150] {
+		t.Errorf("the message after the gap should be returned to playback from, got %v", first)
+	}
+
+	//no checkpoint
+	if err := os.Remove(src.checkpointPath()); err != nil {
+		t.Fatal(err)
+	}
+	if first, ok := b.warmStart(); !ok || first != nil {
+		t.Errorf("the order book should playback without a checkpoint, got %v", first)
+	}
+}
@@ -0,0 +1,85 @@
+package orderbook
+
+import (
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/stream"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/services/log"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/base"
+)
+
+//contractSize parses a size of futures, a number of lots,
+//the size scale of the lot size 1 has no decimals, so a fraction of a contract fails to parse until the scale is widened
+func (b *Builder) contractSize(size string) (int64, error) {
+	ret, err := b.fullOrderBook.ParseSize(size)
+	if err != nil {
+		return 0, b.parseError(err, "", size)
+	}
+	if ret < 0 {
+		log.Panic("contract size panic, negative number of contracts: " + size)
+	}
+
+	return ret, nil
+}
+
+func (b *Builder) updateFutureOrderBook(msg *stream.Event) error {
+	switch data := msg.Data.(type) {
+	case *stream.FutureDataReceivedModel:
+
+	case *stream.FutureDataOpenModel:
+		if data.Price == "" || data.Price == "0" {
+			return nil
+		}
+		if size, err := b.contractSize(data.Size); err != nil || size == 0 {
+			return err
+		}
+
+		side := ""
+		switch data.Side {
+		case stream.SellSide:
+			side = base.AskSide
+		case stream.BuySide:
+			side = base.BidSide
+		default:
+			panic("error side: " + data.Side)
+		}
+
+		order, err := b.fullOrderBook.ParseOrder(data.OrderId, side, data.Price, data.Size, data.OrderTime, nil)
+		if err != nil {
+			return b.parseError(err, data.Price, data.Size)
+		}
+		order.Sequence = msg.Sequence
+		if err := b.fullOrderBook.AddOrder(order); err != nil {
+			log.Panic("AddOrder panic: " + err.Error())
+		}
+		b.OrderBookTime = data.Time
+
+	case *stream.FutureDataUpdateModel:
+		size, err := b.contractSize(data.Size)
+		if err != nil {
+			return err
+		}
+		if err := b.fullOrderBook.ChangeOrder(data.OrderId, size, data.Time); err != nil {
+			log.Panic("UpdateOrder panic: " + err.Error())
+		}
+		b.OrderBookTime = data.Time
+
+	case *stream.FutureDataMatchModel:
+		size, err := b.contractSize(data.Size)
+		if err != nil {
+			return err
+		}
+		if err := b.fullOrderBook.MatchOrder(data.MakerOrderId, size, data.Time); err != nil {
+			log.Panic("MatchOrder panic: " + err.Error())
+		}
+		b.OrderBookTime = data.Time
+
+	case *stream.FutureDataDoneModel:
+		if err := b.fullOrderBook.RemoveByOrderId(data.OrderId); err != nil {
+			log.Panic("RemoveByOrderId panic: " + err.Error())
+		}
+		b.OrderBookTime = data.Time
+
+	default:
+		log.Panic("error msg type: " + msg.Type)
+	}
+	return nil
+}
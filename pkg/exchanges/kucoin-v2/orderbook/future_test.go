@@ -0,0 +1,105 @@
+package orderbook
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/sdk"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/stream"
+)
+
+func readFutureSnapshot(t *testing.T, name string) *DepthResponse {
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	//decode numbers as the http client does
+	decoder := json.NewDecoder(f)
+	decoder.UseNumber()
+	depth := &DepthResponse{}
+	if err := decoder.Decode(depth); err != nil {
+		t.Fatal(err)
+	}
+	return depth
+}
+
+func readFutureStream(t *testing.T, name string) []*stream.Event {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var messages []*sdk.WebSocketDownstreamMessage
+	if err := json.Unmarshal(data, &messages); err != nil {
+		t.Fatal(err)
+	}
+
+	ret := make([]*stream.Event, 0, len(messages))
+	for _, msg := range messages {
+		l3Data, err := stream.NewEvent(msg, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ret = append(ret, l3Data)
+	}
+	return ret
+}
+
+//TestFutureStreamMatchesSnapshot replays the stream between two snapshots,
+//the fixtures are hand-written in the schema of the level3v2 futures messages, not captured from the exchange yet
+func TestFutureStreamMatchesSnapshot(t *testing.T) {
+	b := NewBuilder(nil, "XBTUSDM", Options{ReorderWindow: 10, ReorderTimeout: time.Minute, Future: true, PriceIncrement: "0.5", SizeIncrement: "1", Multiplier: "-1"})
+	if unit, multiplier := b.SizeUnit(); unit != "lot" || multiplier != "-1" {
+		t.Errorf("the sizes of futures should be lots of the multiplier, got %s, %s", unit, multiplier)
+	}
+	b.resetOrderBook()
+	b.AddDepthToOrderBook(readFutureSnapshot(t, "testdata/future_snapshot_start.json"))
+
+	for _, msg := range readFutureStream(t, "testdata/future_stream.json") {
+		if err := b.updateFromStream(msg); err != nil {
+			t.Fatalf("updateFromStream error: %v", err)
+		}
+	}
+
+	got, err := b.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := b.DepthResponse2FullOrderBook(readFutureSnapshot(t, "testdata/future_snapshot_end.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("order book does not match the futures snapshot\ngot:  %+v\nwant: %+v", got, want)
+	}
+}
+
+func TestFutureMatchSubtractsSize(t *testing.T) {
+	b := NewBuilder(nil, "XBTUSDM", Options{Future: true, PriceIncrement: "0.5", SizeIncrement: "1"})
+	b.resetOrderBook()
+	b.AddDepthToOrderBook(readFutureSnapshot(t, "testdata/future_snapshot_start.json"))
+
+	msg := newTestEvent(t, stream.FutureMessageMatchType, map[string]interface{}{
+		"sequence":     101,
+		"side":         stream.SellSide,
+		"price":        "3599",
+		"size":         "30",
+		"takerOrderId": "t1",
+		"makerOrderId": "b1",
+		"ts":           101,
+	}, true)
+	if err := b.updateFromStream(msg); err != nil {
+		t.Fatalf("updateFromStream error: %v", err)
+	}
+
+	if size := b.fullOrderBook.FormatSize(b.fullOrderBook.GetOrder("b1").Size); size != "170" {
+		t.Errorf("size of b1 should be 170 contracts, not %s", size)
+	}
+}
@@ -0,0 +1,153 @@
+package orderbook
+
+import (
+	"errors"
+
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/stream"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/base"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/level3"
+	"github.com/shopspring/decimal"
+)
+
+//extra decimals of the average price and the slippage
+const impactDecimals = 4
+
+//GetMarketImpact walks the order book to simulate the fill of a market order,
+//along with an error wrapping exchanges.ErrOrderBookNotLive unless the order book is live
+func (b *Builder) GetMarketImpact(order *exchanges.MarketOrder) (*exchanges.MarketImpact, error) {
+	view := b.loadView()
+	data, err := simulateMarketOrder(view.book, view.multiplier, order)
+	if err != nil {
+		return nil, err
+	}
+	data.State = view.state.String()
+	data.Sequence = view.sequence
+
+	return data, view.checkLive(b.symbol)
+}
+
+//lotValue returns the value of a lot at price, in the quote currency of a linear contract or spot,
+//or in the base currency of an inverse contract of a negative multiplier
+func lotValue(price, multiplier decimal.Decimal) decimal.Decimal {
+	if multiplier.IsNegative() {
+		return multiplier.Neg().Div(price)
+	}
+
+	return price.Mul(multiplier)
+}
+
+//simulateMarketOrder walks the levels to fill the order, the sizes of futures are lots of multiplier each,
+//so the funds are the values of the lots, multiplier is empty of spot
+func simulateMarketOrder(ob *level3.View, multiplier string, order *exchanges.MarketOrder) (*exchanges.MarketImpact, error) {
+	var side string
+	switch order.Side {
+	case stream.BuySide:
+		side = base.AskSide
+	case stream.SellSide:
+		side = base.BidSide
+	default:
+		return nil, errors.New("side should be buy or sell: " + order.Side)
+	}
+
+	if (order.Size == "") == (order.Funds == "") {
+		return nil, errors.New("either size or funds is required")
+	}
+
+	var size, funds decimal.Decimal
+	var err error
+	if order.Size != "" {
+		if size, err = decimal.NewFromString(order.Size); err != nil || !size.IsPositive() {
+			return nil, errors.New("invalid size: " + order.Size)
+		}
+	} else {
+		if funds, err = decimal.NewFromString(order.Funds); err != nil || !funds.IsPositive() {
+			return nil, errors.New("invalid funds: " + order.Funds)
+		}
+	}
+
+	lot := decimal.New(1, 0)
+	if multiplier != "" {
+		if lot, err = decimal.NewFromString(multiplier); err != nil || lot.IsZero() {
+			return nil, errors.New("invalid multiplier: " + multiplier)
+		}
+	}
+
+	var limitPrice int64
+	hasLimit := order.LimitPrice != ""
+	if hasLimit {
+		if limitPrice, err = ob.PriceScale.Parse(order.LimitPrice); err != nil {
+			return nil, errors.New("invalid limitPrice: " + order.LimitPrice)
+		}
+	}
+
+	priceDecimals := ob.PriceScale.Decimals
+	sizeDecimals := ob.SizeScale.Decimals
+	filledSize, filledFunds := decimal.Zero, decimal.Zero
+	notional := decimal.Zero //price * size of the fills, for the average price
+	var worstPrice int64
+	levels := 0
+	complete := false
+
+	ob.RangeLevels(side, func(level *level3.LevelView) bool {
+		if hasLimit && (side == base.AskSide && level.Price > limitPrice || side == base.BidSide && level.Price < limitPrice) {
+			return false
+		}
+
+		price := decimal.New(level.Price, -priceDecimals)
+		levelSize := decimal.New(level.Size, -sizeDecimals)
+		value := lotValue(price, lot)
+
+		fillSize := levelSize
+		if order.Size != "" {
+			if remain := size.Sub(filledSize); remain.LessThanOrEqual(levelSize) {
+				fillSize, complete = remain, true
+			}
+		} else {
+			//the size bought by the remaining funds, rounded down to the size decimals
+			if remain := funds.Sub(filledFunds); remain.LessThanOrEqual(value.Mul(levelSize)) {
+				fillSize, complete = remain.Div(value).Truncate(sizeDecimals), true
+			}
+		}
+
+		if fillSize.IsPositive() {
+			filledSize = filledSize.Add(fillSize)
+			filledFunds = filledFunds.Add(fillSize.Mul(value))
+			notional = notional.Add(fillSize.Mul(price))
+			worstPrice = level.Price
+			levels++
+		}
+		return !complete
+	})
+
+	data := &exchanges.MarketImpact{
+		Side:        order.Side,
+		FilledSize:  filledSize.String(),
+		FilledFunds: filledFunds.String(),
+		Levels:      levels,
+		Complete:    complete,
+	}
+	if levels == 0 {
+		return data, nil
+	}
+
+	average := notional.DivRound(filledSize, priceDecimals+impactDecimals)
+	data.AveragePrice = average.String()
+	data.WorstPrice = ob.FormatPrice(worstPrice)
+
+	ask, bid := ob.GetOrderBookTickerOrder()
+	if ask == nil || bid == nil {
+		return data, nil
+	}
+
+	mid := decimal.New(ask.Price+bid.Price, -priceDecimals).Div(decimal.New(2, 0))
+	slippage := average.Sub(mid)
+	if order.Side == stream.SellSide {
+		slippage = slippage.Neg()
+	}
+	data.MidPrice = mid.String()
+	data.Slippage = slippage.Round(priceDecimals + impactDecimals).String()
+	data.SlippageBps = slippage.Div(mid).Mul(decimal.New(10000, 0)).Round(2).String()
+
+	return data, nil
+}
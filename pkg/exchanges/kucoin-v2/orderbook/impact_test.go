@@ -0,0 +1,151 @@
+package orderbook
+
+import (
+	"testing"
+
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/base"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/fixed"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/level3"
+)
+
+func TestSimulateMarketOrder(t *testing.T) {
+	price, _ := fixed.NewScale(2)
+	size, _ := fixed.NewScale(4)
+	ob := level3.NewOrderBook(price, size)
+	for i, o := range [][3]string{
+		{base.AskSide, "10.1", "1"},
+		{base.AskSide, "10.1", "1"},
+		{base.AskSide, "10.2", "2"},
+		{base.AskSide, "10.5", "5"},
+		{base.BidSide, "9.9", "3"},
+	} {
+		order, err := ob.ParseOrder(string(rune('a'+i)), o[0], o[1], o[2], uint64(i), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ob.AddOrder(order); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		order exchanges.MarketOrder
+		want  exchanges.MarketImpact
+	}{
+		{
+			exchanges.MarketOrder{Side: "buy", Size: "3"},
+			exchanges.MarketImpact{Side: "buy", FilledSize: "3", FilledFunds: "30.4", AveragePrice: "10.133333", WorstPrice: "10.2", Levels: 2,
+				MidPrice: "10", Slippage: "0.133333", SlippageBps: "133.33", Complete: true},
+		},
+		{
+			exchanges.MarketOrder{Side: "buy", Funds: "25"},
+			exchanges.MarketImpact{Side: "buy", FilledSize: "2.4705", FilledFunds: "24.9991", AveragePrice: "10.119045", WorstPrice: "10.2", Levels: 2,
+				MidPrice: "10", Slippage: "0.119045", SlippageBps: "119.05", Complete: true},
+		},
+		{
+			exchanges.MarketOrder{Side: "buy", Size: "10", LimitPrice: "10.2"},
+			exchanges.MarketImpact{Side: "buy", FilledSize: "4", FilledFunds: "40.6", AveragePrice: "10.15", WorstPrice: "10.2", Levels: 2,
+				MidPrice: "10", Slippage: "0.15", SlippageBps: "150", Complete: false},
+		},
+		{
+			exchanges.MarketOrder{Side: "sell", Size: "1"},
+			exchanges.MarketImpact{Side: "sell", FilledSize: "1", FilledFunds: "9.9", AveragePrice: "9.9", WorstPrice: "9.9", Levels: 1,
+				MidPrice: "10", Slippage: "0.1", SlippageBps: "100", Complete: true},
+		},
+		{
+			exchanges.MarketOrder{Side: "sell", Size: "1", LimitPrice: "10"},
+			exchanges.MarketImpact{Side: "sell", FilledSize: "0", FilledFunds: "0"},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := simulateMarketOrder(ob.View(), "", &test.order)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *got != test.want {
+			t.Errorf("%+v: got %+v, want %+v", test.order, *got, test.want)
+		}
+	}
+
+	for _, order := range []exchanges.MarketOrder{
+		{Side: "long", Size: "1"},
+		{Side: "buy"},
+		{Side: "buy", Size: "1", Funds: "10"},
+		{Side: "buy", Size: "-1"},
+		{Side: "buy", Size: "1", LimitPrice: "10.001"},
+	} {
+		if _, err := simulateMarketOrder(ob.View(), "", &order); err == nil {
+			t.Errorf("%+v should be invalid", order)
+		}
+	}
+}
+
+//TestSimulateFuturesMarketOrder checks the funds of futures are the values of the lots,
+//multiplier units of the base currency, or of the quote currency of an inverse contract
+func TestSimulateFuturesMarketOrder(t *testing.T) {
+	price, _ := fixed.NewScale(1)
+	size, _ := fixed.NewScale(0)
+	ob := level3.NewOrderBook(price, size)
+	for i, o := range [][3]string{
+		{base.AskSide, "20000", "10"},
+		{base.AskSide, "25000", "10"},
+		{base.BidSide, "19000", "10"},
+	} {
+		order, err := ob.ParseOrder(string(rune('a'+i)), o[0], o[1], o[2], uint64(i), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ob.AddOrder(order); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		multiplier string
+		order      exchanges.MarketOrder
+		want       exchanges.MarketImpact
+	}{
+		{
+			//a lot of 0.001 base, 15 lots cost 10 * 20 + 5 * 25 quote
+			"0.001",
+			exchanges.MarketOrder{Side: "buy", Size: "15"},
+			exchanges.MarketImpact{Side: "buy", FilledSize: "15", FilledFunds: "325", AveragePrice: "21666.66667", WorstPrice: "25000", Levels: 2,
+				MidPrice: "19500", Slippage: "2166.66667", SlippageBps: "1111.11", Complete: true},
+		},
+		{
+			"0.001",
+			exchanges.MarketOrder{Side: "buy", Funds: "300"},
+			exchanges.MarketImpact{Side: "buy", FilledSize: "14", FilledFunds: "300", AveragePrice: "21428.57143", WorstPrice: "25000", Levels: 2,
+				MidPrice: "19500", Slippage: "1928.57143", SlippageBps: "989.01", Complete: true},
+		},
+		{
+			//an inverse lot of 1 quote, 10 lots at 20000 cost 10 / 20000 base
+			"-1",
+			exchanges.MarketOrder{Side: "buy", Size: "15"},
+			exchanges.MarketImpact{Side: "buy", FilledSize: "15", FilledFunds: "0.0007", AveragePrice: "21666.66667", WorstPrice: "25000", Levels: 2,
+				MidPrice: "19500", Slippage: "2166.66667", SlippageBps: "1111.11", Complete: true},
+		},
+		{
+			"-1",
+			exchanges.MarketOrder{Side: "sell", Funds: "0.0001"},
+			exchanges.MarketImpact{Side: "sell", FilledSize: "1", FilledFunds: "0.0000526315789474", AveragePrice: "19000", WorstPrice: "19000", Levels: 1,
+				MidPrice: "19500", Slippage: "500", SlippageBps: "256.41", Complete: true},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := simulateMarketOrder(ob.View(), test.multiplier, &test.order)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *got != test.want {
+			t.Errorf("%s %+v: got %+v, want %+v", test.multiplier, test.order, *got, test.want)
+		}
+	}
+
+	if _, err := simulateMarketOrder(ob.View(), "0", &exchanges.MarketOrder{Side: "buy", Size: "1"}); err == nil {
+		t.Errorf("a zero multiplier should be invalid")
+	}
+}
@@ -1,11 +1,13 @@
 package orderbook
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Kucoin/kucoin-level3-sdk/pkg/consts"
@@ -14,8 +16,8 @@ import (
 	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/stream"
 	"github.com/Kucoin/kucoin-level3-sdk/pkg/services/log"
 	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/base"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/fixed"
 	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/level3"
-	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 )
 
@@ -23,26 +25,228 @@ type Builder struct {
 	apiService *sdk.Kucoin
 	symbol     string
 	lock       *sync.RWMutex
-	Messages   chan *sdk.WebSocketDownstreamMessage
+	Messages   chan *stream.Event
 
 	OrderBookTime uint64
 	Sequence      uint64 //Sequence || UpdateID
 	fullOrderBook *level3.OrderBook
+	state         State
+	resync        uint32 //set to 1 to rebuild the order book from a new snapshot
+	syncStats     SyncStats
+	reorder       *reorderBuffer
+	future        bool
+
+	//fetchSnapshot fetches the snapshot to playback on, GetAtomicFullOrderBook unless stubbed in tests
+	fetchSnapshot func() (*DepthResponse, error)
+
+	//prices and sizes are int64 of the scales of the symbol increments
+	scaled     bool
+	priceScale fixed.Scale
+	sizeScale  fixed.Scale
+	priceTick  int64
+
+	//the increments of the scales and the multiplier of a futures lot, written to the checkpoints
+	priceIncrement string
+	sizeIncrement  string
+	multiplier     string
+
+	checkpointDir      string
+	checkpointInterval time.Duration
+
+	stats *bookStats
+
+	//the writer publishes an immutable view after every update, readers never take the lock to load it
+	view      atomic.Value //*bookView
+	viewBook  *level3.OrderBook
+	viewDepth int
 }
 
-func NewBuilder(apiService *sdk.Kucoin, symbol string) *Builder {
-	return &Builder{
+type Options struct {
+	//hold up to ReorderWindow messages arriving before their previous sequence for ReorderTimeout,
+	//before resyncing the order book
+	ReorderWindow  int
+	ReorderTimeout time.Duration
+
+	//Future builds the order book of futures from the level3v2 stream
+	Future bool
+
+	//the increments of the symbol and the units of the base currency of a futures lot,
+	//they are fetched from the symbol metadata when empty
+	PriceIncrement string
+	SizeIncrement  string
+	Multiplier     string
+
+	//GetBookStats reports the volume imbalance of the best StatsDepth levels,
+	//and the cumulative depth within each of StatsBands bps of the mid price
+	StatsDepth int
+	StatsBands []int
+
+	//the best ViewDepth levels and orders are materialized for the readers after every update,
+	//defaultViewDepth if 0, more levels are read from the published view of the full order book
+	ViewDepth int
+
+	//the length of Messages, consts.MaxMsgChanLen if 0
+	ChanLen int
+
+	//a checkpoint of the live order book is written to CheckpointDir every CheckpointInterval,
+	//it is loaded on start and bridged to the stream instead of fetching a snapshot, disabled if CheckpointInterval is 0
+	CheckpointDir      string
+	CheckpointInterval time.Duration
+}
+
+func NewBuilder(apiService *sdk.Kucoin, symbol string, options Options) *Builder {
+	if options.ChanLen <= 0 {
+		options.ChanLen = consts.MaxMsgChanLen
+	}
+
+	b := &Builder{
 		apiService: apiService,
 		symbol:     symbol,
 		lock:       &sync.RWMutex{},
-		Messages:   make(chan *sdk.WebSocketDownstreamMessage, consts.MaxMsgChanLen),
+		state:      StateInitializing,
+		Messages:   make(chan *stream.Event, options.ChanLen),
+		reorder:    newReorderBuffer(options.ReorderWindow, options.ReorderTimeout),
+		future:     options.Future,
+		stats:      newBookStats(options.StatsDepth, options.StatsBands),
+
+		viewDepth: options.ViewDepth,
+
+		checkpointDir:      options.CheckpointDir,
+		checkpointInterval: options.CheckpointInterval,
+	}
+	if b.viewDepth <= 0 {
+		b.viewDepth = defaultViewDepth
+	}
+	b.fetchSnapshot = b.GetAtomicFullOrderBook
+	if options.PriceIncrement != "" && options.SizeIncrement != "" {
+		if err := b.setScales(options.PriceIncrement, options.SizeIncrement, options.Multiplier); err != nil {
+			log.Panic("NewBuilder panic", zap.Error(err))
+		}
+	}
+	b.fullOrderBook = level3.NewOrderBook(b.priceScale, b.sizeScale)
+	b.publish()
+
+	return b
+}
+
+func (b *Builder) setScales(priceIncrement, sizeIncrement, multiplier string) error {
+	priceScale, err := fixed.ScaleOf(priceIncrement)
+	if err != nil {
+		return err
+	}
+	sizeScale, err := fixed.ScaleOf(sizeIncrement)
+	if err != nil {
+		return err
+	}
+
+	priceTick, err := priceScale.Parse(priceIncrement)
+	if err != nil {
+		return err
+	}
+
+	b.priceScale, b.sizeScale, b.priceTick, b.scaled = priceScale, sizeScale, priceTick, true
+	b.priceIncrement, b.sizeIncrement, b.multiplier = priceIncrement, sizeIncrement, multiplier
+	log.Info(fmt.Sprintf("symbol: %s, price decimals: %d, size decimals: %d", b.symbol, priceScale.Decimals, sizeScale.Decimals))
+	return nil
+}
+
+//widenScales returns the scales widened to the decimals of price and size
+func widenScales(priceScale, sizeScale fixed.Scale, price, size string) (fixed.Scale, fixed.Scale, error) {
+	if scale, err := fixed.ScaleOf(price); err != nil {
+		return priceScale, sizeScale, err
+	} else if scale.Decimals > priceScale.Decimals {
+		priceScale = scale
+	}
+	if scale, err := fixed.ScaleOf(size); err != nil {
+		return priceScale, sizeScale, err
+	} else if scale.Decimals > sizeScale.Decimals {
+		sizeScale = scale
+	}
+
+	return priceScale, sizeScale, nil
+}
+
+//depthScales returns the scales widened to the maximum decimals of the prices and sizes of the snapshot
+func depthScales(depth *DepthResponse, priceScale, sizeScale fixed.Scale) (fixed.Scale, fixed.Scale, error) {
+	for _, elems := range [][][4]interface{}{depth.Asks, depth.Bids} {
+		for _, elem := range elems {
+			var err error
+			priceScale, sizeScale, err = widenScales(priceScale, sizeScale, elemString(elem[1]), elemString(elem[2]))
+			if err != nil {
+				return priceScale, sizeScale, err
+			}
+		}
+	}
+
+	return priceScale, sizeScale, nil
+}
+
+//setWiderScales makes the next order books in the scales, wider when the exchange refined an increment
+//while the orders of the old one are resting, the caller must hold the lock
+func (b *Builder) setWiderScales(priceScale, sizeScale fixed.Scale) error {
+	if priceScale == b.priceScale && sizeScale == b.sizeScale {
+		return nil
+	}
+
+	priceTick, err := priceScale.Parse(b.priceIncrement)
+	if err != nil {
+		return err
+	}
+
+	b.priceScale, b.sizeScale, b.priceTick = priceScale, sizeScale, priceTick
+	log.Warn(fmt.Sprintf("symbol: %s, more decimals than the increments, price decimals: %d, size decimals: %d", b.symbol, priceScale.Decimals, sizeScale.Decimals))
+	return nil
+}
+
+//parseError widens the scales to a price or size of more decimals than them,
+//so the resync rebuilds the order book in the wider scales, the caller must hold the lock
+func (b *Builder) parseError(err error, price, size string) error {
+	if !errors.Is(err, fixed.ErrDecimals) {
+		return err
+	}
+
+	priceScale, sizeScale, widenErr := widenScales(b.priceScale, b.sizeScale, price, size)
+	if widenErr != nil {
+		return widenErr
+	}
+	if widenErr := b.setWiderScales(priceScale, sizeScale); widenErr != nil {
+		return widenErr
+	}
+
+	return err
+}
+
+//loadScales fetches the increments of the symbol once, and the multiplier of futures missing in an older checkpoint
+func (b *Builder) loadScales() error {
+	if b.scaled && (!b.future || b.multiplier != "") {
+		return nil
+	}
+
+	priceIncrement, sizeIncrement, multiplier, err := b.apiService.SymbolIncrements(b.symbol)
+	if err != nil {
+		return err
+	}
+
+	return b.setScales(priceIncrement, sizeIncrement, multiplier)
+}
+
+//SizeUnit returns the unit of the sizes, "base" of spot, "lot" of futures, a number of contracts of multiplier units each,
+//a negative multiplier is of the quote currency of an inverse contract
+func (b *Builder) SizeUnit() (unit, multiplier string) {
+	if !b.future {
+		return "base", ""
 	}
+
+	return "lot", b.loadView().multiplier
 }
 
 func (b *Builder) resetOrderBook() {
 	b.lock.Lock()
-	b.fullOrderBook = level3.NewOrderBook()
+	b.fullOrderBook = level3.NewOrderBook(b.priceScale, b.sizeScale)
+	b.reorder.reset()
+	b.publish()
 	b.lock.Unlock()
+	atomic.StoreUint32(&b.resync, 0)
 }
 
 func (b *Builder) ReloadOrderBook() {
@@ -53,32 +257,186 @@ func (b *Builder) ReloadOrderBook() {
 	}()
 
 	log.Info("start running ReloadOrderBook, symbol: " + b.symbol)
+	if b.checkpoints() {
+		done := make(chan struct{})
+		defer close(done)
+		go b.runCheckpoints(done)
+	}
+
+	first, ok := b.warmStart()
+	for ok {
+		if first, ok = b.reload(first); ok {
+			b.countResync()
+		}
+	}
+
+	log.Info("stop running ReloadOrderBook, symbol: " + b.symbol)
+}
+
+func (b *Builder) countResync() {
+	b.lock.Lock()
+	b.syncStats.Resyncs++
+	b.lock.Unlock()
+	log.Info("resync order book, symbol: " + b.symbol)
+}
+
+//reload rebuilds the order book by playback and keeps it updated until a resync is needed,
+//the message which broke the sequence is returned to start the next playback,
+//ok is false when the message channel is closed
+func (b *Builder) reload(first *stream.Event) (next *stream.Event, ok bool) {
 	b.resetOrderBook()
 
-	b.playback()
+	ok, err := b.playback(first)
+	if !ok {
+		return nil, false
+	}
+	if err != nil {
+		log.Warn("playback failed, resync, symbol: "+b.symbol, zap.Error(err))
+		b.setState(StateResyncing)
+		return nil, true
+	}
 
-	for msg := range b.Messages {
-		l3Data, err := stream.NewStreamDataModel(msg)
-		if err != nil {
-			log.Panic("NewStreamDataModel panic", zap.Error(err))
+	return b.follow()
+}
+
+//follow keeps the live order book updated until a resync is needed
+func (b *Builder) follow() (next *stream.Event, ok bool) {
+	for {
+		l3Data, ok := b.nextMessage()
+		if !ok {
+			break
+		}
+
+		if atomic.LoadUint32(&b.resync) == 1 {
+			return nil, true
 		}
-		b.updateFromStream(l3Data)
+
+		if err := b.applyMessage(l3Data); err != nil {
+			log.Warn("order book out of sync, resync, symbol: "+b.symbol, zap.Error(err))
+			var gapErr *SequenceGapError
+			if errors.As(err, &gapErr) {
+				b.lock.Lock()
+				b.syncStats.addGap(gapErr.Size())
+				b.lock.Unlock()
+			}
+			b.setState(StateResyncing)
+			return l3Data, true
+		}
+	}
+
+	return nil, false
+}
+
+//Resync marks the order book as resyncing and rebuilds it from a new snapshot,
+//e.g. after the websocket reconnected and some messages may be lost
+func (b *Builder) Resync() {
+	b.setState(StateResyncing)
+	atomic.StoreUint32(&b.resync, 1)
+}
+
+func (b *Builder) setState(state State) {
+	b.lock.Lock()
+	old := b.state
+	b.state = state
+	b.publish()
+	b.lock.Unlock()
+
+	if old != state {
+		log.Info("order book state: "+old.String()+" => "+state.String(), zap.String("symbol", b.symbol))
 	}
 }
 
-func (b *Builder) playback() {
-	log.Info("prepare playback...")
+//MarkStale marks a live order book as stale until the next message is applied,
+//it returns false if the order book is not live
+func (b *Builder) MarkStale() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.state != StateLive {
+		return false
+	}
+
+	b.state = StateStale
+	b.publish()
+	log.Info("order book state: "+StateLive.String()+" => "+StateStale.String(), zap.String("symbol", b.symbol))
+	return true
+}
+
+//Status returns the state and the current sequence
+func (b *Builder) Status() (state State, sequence uint64) {
+	view := b.loadView()
+	return view.state, view.sequence
+}
+
+func checkLive(symbol string, state State) error {
+	if state != StateLive {
+		return fmt.Errorf("%w, symbol: %s, state: %s", exchanges.ErrOrderBookNotLive, symbol, state)
+	}
+
+	return nil
+}
+
+func (b *Builder) SyncStats() SyncStats {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	return b.syncStats.copy()
+}
+
+//playback buffers the stream from first until a snapshot covering it is fetched, then replays the buffer on the snapshot,
+//ok is false when the message channel is closed, err is the replay error to resync from
+func (b *Builder) playback(first *stream.Event) (ok bool, err error) {
+	log.Info("prepare playback..., symbol: " + b.symbol)
+	if state, _ := b.Status(); state == StateInitializing {
+		b.setState(StatePlayback)
+	}
 
 	const tempMsgChanMaxLen = 10240
-	tempMsgChan := make(chan *stream.DataModel, tempMsgChanMaxLen)
+	tempMsgChan := make(chan *stream.Event, tempMsgChanMaxLen)
 	firstSequence := uint64(0)
+	lastSequence := uint64(0)
 	var fullOrderBook *DepthResponse
+	var nextFetchTime time.Time
 
-	for msg := range b.Messages {
-		l3Data, err := stream.NewStreamDataModel(msg)
-		if err != nil {
-			log.Panic("NewStreamDataModel panic", zap.Error(err))
+	restart := func() {
+		for len(tempMsgChan) > 0 {
+			<-tempMsgChan
+		}
+		firstSequence = 0
+		fullOrderBook = nil
+	}
+
+	for {
+		l3Data := first
+		first = nil
+		if l3Data == nil {
+			l3Data, ok = b.nextMessage()
+			if !ok {
+				return false, nil
+			}
+			if l3Data == nil {
+				//the reorder deadline, no message is held during playback
+				continue
+			}
 		}
+
+		if lastSequence != 0 && l3Data.Sequence <= lastSequence {
+			continue
+		}
+
+		if lastSequence != 0 && l3Data.Sequence != lastSequence+1 {
+			log.Warn(fmt.Sprintf(
+				"playback sequence is not continuous, lastSequence: %d, msgSequence: %d, restart buffering",
+				lastSequence,
+				l3Data.Sequence,
+			))
+			b.lock.Lock()
+			b.syncStats.addGap(l3Data.Sequence - lastSequence - 1)
+			b.lock.Unlock()
+			restart()
+		}
+		lastSequence = l3Data.Sequence
+
 		tempMsgChan <- l3Data
 
 		if firstSequence == 0 {
@@ -88,17 +446,33 @@ func (b *Builder) playback() {
 
 		if len(tempMsgChan) > 5 {
 			if fullOrderBook == nil {
+				if time.Now().Before(nextFetchTime) {
+					continue
+				}
+
+				if err := b.loadScales(); err != nil {
+					log.Error("SymbolIncrements failed, retry later", zap.Error(err))
+					b.setState(StateFailed)
+					nextFetchTime = time.Now().Add(time.Second)
+					continue
+				}
+
 				log.Info("start GetAtomicFullOrderBook , symbol: " + b.symbol)
-				fullOrderBook, err = b.GetAtomicFullOrderBook()
+				fullOrderBook, err = b.fetchSnapshot()
 				if err != nil {
-					log.Panic("GetAtomicFullOrderBook panic", zap.Error(err))
+					log.Error("GetAtomicFullOrderBook failed, retry later", zap.Error(err))
+					b.setState(StateFailed)
+					nextFetchTime = time.Now().Add(time.Second)
 					continue
 				}
 				log.Info(fmt.Sprintf("finish GetAtomicFullOrderBook, Sequence: %d", fullOrderBook.Sequence))
 			}
 
 			if len(tempMsgChan) > tempMsgChanMaxLen-5 {
-				log.Panic("playback failed, tempMsgChan is too long, retry...")
+				log.Warn("playback failed, tempMsgChan is too long, retry...")
+				b.setState(StateFailed)
+				restart()
+				continue
 			}
 
 			if fullOrderBook != nil && fullOrderBook.Sequence < firstSequence {
@@ -111,111 +485,185 @@ func (b *Builder) playback() {
 				log.Info("sequence match, start playback, tempMsgChan: " + strconv.Itoa(len(tempMsgChan)))
 
 				b.lock.Lock()
-				b.AddDepthToOrderBook(fullOrderBook)
+				priceScale, sizeScale, err := depthScales(fullOrderBook, b.priceScale, b.sizeScale)
+				if err == nil {
+					err = b.setWiderScales(priceScale, sizeScale)
+				}
+				if err == nil {
+					b.fullOrderBook = level3.NewOrderBook(b.priceScale, b.sizeScale)
+					err = b.AddDepthToOrderBook(fullOrderBook)
+				}
 				b.lock.Unlock()
+				if err != nil {
+					return true, err
+				}
 
 				n := len(tempMsgChan)
 				for i := 0; i < n; i++ {
-					b.updateFromStream(<-tempMsgChan)
+					if err := b.updateFromStream(<-tempMsgChan); err != nil {
+						return true, err
+					}
 				}
 
-				log.Info("finish playback.")
-				break
+				b.setState(StateLive)
+
+				log.Info("finish playback, symbol: " + b.symbol)
+				return true, nil
 			}
 		}
 	}
 }
 
-func newOrderWithElem(side string, elem [4]interface{}, info interface{}) (*level3.Order, error) {
+func newOrderWithElem(fullOrderBook *level3.OrderBook, side string, elem [4]interface{}, info interface{}) (*level3.Order, error) {
 	timeInt, err := elem[3].(json.Number).Int64()
 	if err != nil {
 		return nil, err
 	}
-	return level3.NewOrder(elem[0].(string), side, elem[1].(string), elem[2].(string), uint64(timeInt), info)
+	return fullOrderBook.ParseOrder(elem[0].(string), side, elemString(elem[1]), elemString(elem[2]), uint64(timeInt), info)
+}
+
+//elemString returns the price or size of a snapshot order, the futures snapshot has numbers instead of strings
+func elemString(elem interface{}) string {
+	switch val := elem.(type) {
+	case json.Number:
+		return val.String()
+	case string:
+		return val
+	}
+
+	panic(fmt.Sprintf("error snapshot elem: %#v", elem))
 }
 
-func (b *Builder) AddDepthToOrderBook(depth *DepthResponse) {
+func (b *Builder) AddDepthToOrderBook(depth *DepthResponse) error {
 	b.Sequence = depth.Sequence
 	b.OrderBookTime = uint64(time.Now().UnixNano())
-	b.formatDepthToOrderBook(depth, b.fullOrderBook)
+	if err := b.formatDepthToOrderBook(depth, b.fullOrderBook); err != nil {
+		return err
+	}
+	b.publish()
+	return nil
 }
 
-func (b *Builder) formatDepthToOrderBook(depth *DepthResponse, fullOrderBook *level3.OrderBook) {
+func (b *Builder) formatDepthToOrderBook(depth *DepthResponse, fullOrderBook *level3.OrderBook) error {
 	fullOrderBook.Sequence = depth.Sequence
 
 	for _, elem := range depth.Asks {
-		order, err := newOrderWithElem(base.AskSide, elem, nil)
+		order, err := newOrderWithElem(fullOrderBook, base.AskSide, elem, nil)
 		if err != nil {
-			log.Panic("NewOrder panic", zap.Error(err))
+			return err
 		}
 
-		if err := fullOrderBook.AddOrder(order); err != nil {
-			log.Panic("AddOrder panic", zap.Error(err))
+		if err := fullOrderBook.AppendOrder(order); err != nil {
+			return err
 		}
 	}
 
 	for _, elem := range depth.Bids {
-		order, err := newOrderWithElem(base.BidSide, elem, nil)
+		order, err := newOrderWithElem(fullOrderBook, base.BidSide, elem, nil)
 		if err != nil {
-			log.Panic("NewOrder panic", zap.Error(err))
+			return err
 		}
 
-		if err := fullOrderBook.AddOrder(order); err != nil {
-			log.Panic("AddOrder panic", zap.Error(err))
+		if err := fullOrderBook.AppendOrder(order); err != nil {
+			return err
 		}
 	}
 
-	return
+	return nil
 }
 
-func (b *Builder) updateFromStream(msg *stream.DataModel) {
+func (b *Builder) updateFromStream(msg *stream.Event) error {
 	b.lock.Lock()
 	defer b.lock.Unlock()
 
-	skip, err := b.updateSequence(msg)
-	if err != nil {
-		log.Panic("updateSequence panic", zap.Error(err))
+	now := time.Now()
+	switch {
+	case msg.Sequence <= b.Sequence:
+		//duplicate message
+		return nil
+
+	case msg.Sequence > b.Sequence+1:
+		b.reorder.add(msg, now)
+		return b.checkReorder(now)
+	}
+
+	b.updateSequence(msg)
+	if err := b.updateOrderBook(msg); err != nil {
+		return err
+	}
+	for next := b.reorder.pop(b.Sequence+1, now); next != nil; next = b.reorder.pop(b.Sequence+1, now) {
+		b.updateSequence(next)
+		if err := b.updateOrderBook(next); err != nil {
+			return err
+		}
 	}
 
-	if !skip {
-		b.updateOrderBook(msg)
+	if b.state == StateStale {
+		b.state = StateLive
+		log.Info("order book state: "+StateStale.String()+" => "+StateLive.String(), zap.String("symbol", b.symbol))
 	}
+	b.publish()
+	return nil
 }
 
-func (b *Builder) updateSequence(msg *stream.DataModel) (bool, error) {
-	if b.Sequence+1 > msg.Sequence {
-		return true, nil
+//checkReorder returns a SequenceGapError if the held messages expired, the caller must hold the lock
+func (b *Builder) checkReorder(now time.Time) error {
+	if !b.reorder.expired(now) {
+		return nil
+	}
+
+	return &SequenceGapError{
+		Sequence:    b.Sequence,
+		MsgSequence: b.reorder.first,
+		ChanLen:     len(b.Messages),
 	}
+}
 
-	if b.Sequence+1 != msg.Sequence {
-		return false, errors.New(fmt.Sprintf(
-			"currentSequence: %d, msgSequence: %d, the sequence is not continuous, current chanLen: %d",
-			b.Sequence,
-			msg.Sequence,
-			len(b.Messages),
-		))
+//applyMessage applies the message, or checks the held messages on the reorder deadline if msg is nil
+func (b *Builder) applyMessage(msg *stream.Event) error {
+	if msg != nil {
+		return b.updateFromStream(msg)
 	}
 
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.checkReorder(time.Now())
+}
+
+func (b *Builder) updateSequence(msg *stream.Event) {
 	b.Sequence = msg.Sequence
 	b.fullOrderBook.Sequence = msg.Sequence
-	return false, nil
 }
 
-func (b *Builder) updateOrderBook(msg *stream.DataModel) {
+//updateOrderBook returns the error of a price or size unparsable in the scales, the order book must be resynced
+func (b *Builder) updateOrderBook(msg *stream.Event) error {
+	var err error
+	if b.future {
+		err = b.updateFutureOrderBook(msg)
+	} else {
+		err = b.updateSpotOrderBook(msg)
+	}
+	if err != nil {
+		return err
+	}
+
+	ask, bid := b.fullOrderBook.GetOrderBookTickerOrder()
+	if ask != nil && bid != nil && bid.Price >= ask.Price {
+		log.Panic("order book cross", zap.String("asks", b.fullOrderBook.FormatPrice(ask.Price)), zap.String("bids", b.fullOrderBook.FormatPrice(bid.Price)))
+	}
+	return nil
+}
+
+func (b *Builder) updateSpotOrderBook(msg *stream.Event) error {
 	//[3]string{"orderId", "price", "size"}
 	//var item = [3]string{msg.OrderId, msg.Price, msg.Size}
 
-	switch msg.Type {
-	case stream.MessageReceivedType:
-
-	case stream.MessageOpenType:
-		data := &stream.DataOpenModel{}
-		if err := json.Unmarshal(msg.Data(), data); err != nil {
-			log.Panic("Unmarshal panic", zap.Error(err))
-		}
+	switch data := msg.Data.(type) {
+	case *stream.DataReceivedModel:
 
+	case *stream.DataOpenModel:
 		if data.Price == "" || data.Size == "0" || data.Price == "0" || data.Size == "" {
-			return
+			return nil
 		}
 
 		side := ""
@@ -228,51 +676,38 @@ func (b *Builder) updateOrderBook(msg *stream.DataModel) {
 			panic("error side: " + data.Side)
 		}
 
-		order, err := level3.NewOrder(data.OrderId, side, data.Price, data.Size, data.Time, nil)
+		order, err := b.fullOrderBook.ParseOrder(data.OrderId, side, data.Price, data.Size, data.OrderTime, nil)
 		if err != nil {
-			log.Panic("NewOrder panic: "+err.Error(), zap.String("Data", string(msg.Data())))
+			return b.parseError(err, data.Price, data.Size)
 		}
+		order.Sequence = msg.Sequence
 		if err := b.fullOrderBook.AddOrder(order); err != nil {
 			log.Panic("AddOrder panic: " + err.Error())
 		}
 		b.OrderBookTime = data.Time
 
-	case stream.MessageDoneType:
-		data := &stream.DataDoneModel{}
-		if err := json.Unmarshal(msg.Data(), data); err != nil {
-			log.Panic("Unmarshal panic", zap.Error(err))
-		}
-
+	case *stream.DataDoneModel:
 		if err := b.fullOrderBook.RemoveByOrderId(data.OrderId); err != nil {
 			log.Panic("RemoveByOrderId panic: " + err.Error())
 		}
 		b.OrderBookTime = data.Time
 
-	case stream.MessageMatchType:
-		data := &stream.DataMatchModel{}
-		if err := json.Unmarshal(msg.Data(), data); err != nil {
-			log.Panic("Unmarshal panic: " + err.Error())
-		}
-		size, err := decimal.NewFromString(data.RemainSize)
+	case *stream.DataMatchModel:
+		size, err := b.fullOrderBook.ParseSize(data.RemainSize)
 		if err != nil {
-			log.Panic("MatchOrder panic: " + err.Error())
+			return b.parseError(err, "", data.RemainSize)
 		}
-		if err := b.fullOrderBook.ChangeOrder(data.MakerOrderId, size); err != nil {
+		if err := b.fullOrderBook.FillOrder(data.MakerOrderId, size, data.Time); err != nil {
 			log.Panic("MatchOrder panic: " + err.Error())
 		}
 		b.OrderBookTime = data.Time
 
-	case stream.MessageUpdateType:
-		data := &stream.DataUpdateModel{}
-		if err := json.Unmarshal(msg.Data(), data); err != nil {
-			log.Panic("Unmarshal panic: " + err.Error())
-		}
-
-		size, err := decimal.NewFromString(data.Size)
+	case *stream.DataUpdateModel:
+		size, err := b.fullOrderBook.ParseSize(data.Size)
 		if err != nil {
-			log.Panic("UpdateOrder panic: " + err.Error())
+			return b.parseError(err, "", data.Size)
 		}
-		if err := b.fullOrderBook.ChangeOrder(data.OrderId, size); err != nil {
+		if err := b.fullOrderBook.ChangeOrder(data.OrderId, size, data.Time); err != nil {
 			log.Panic("UpdateOrder panic: " + err.Error())
 		}
 		b.OrderBookTime = data.Time
@@ -280,11 +715,7 @@ func (b *Builder) updateOrderBook(msg *stream.DataModel) {
 	default:
 		log.Panic("error msg type: " + msg.Type)
 	}
-
-	ask, bid := b.fullOrderBook.GetOrderBookTickerOrder()
-	if ask != nil && bid != nil && bid.Price.Cmp(ask.Price) >= 0 {
-		log.Panic("order book cross", zap.String("asks", ask.Price.String()), zap.String("bids", bid.Price.String()))
-	}
+	return nil
 }
 
 //[3]string{"orderId", "price", "size"}
@@ -294,9 +725,17 @@ type FullOrderBook struct {
 	Bids     [][3]string `json:"bids"`
 }
 
+//DepthResponse2FullOrderBook keeps the queue of the snapshot in each price level,
+//so Verify compares the order book with the exchange order by order
 func (b *Builder) DepthResponse2FullOrderBook(atomicFullOrderBook *DepthResponse) (*FullOrderBook, error) {
-	orderBook := level3.NewOrderBook()
-	b.formatDepthToOrderBook(atomicFullOrderBook, orderBook)
+	priceScale, sizeScale, err := depthScales(atomicFullOrderBook, b.priceScale, b.sizeScale)
+	if err != nil {
+		return nil, err
+	}
+	orderBook := level3.NewOrderBook(priceScale, sizeScale)
+	if err := b.formatDepthToOrderBook(atomicFullOrderBook, orderBook); err != nil {
+		return nil, err
+	}
 	data, err := json.Marshal(orderBook)
 	if err != nil {
 		return nil, err
@@ -324,10 +763,9 @@ func (b *Builder) Snapshot() (*FullOrderBook, error) {
 	return ret, nil
 }
 
+//SnapshotBytes marshals the published view of the full order book, so the writer is not blocked by marshaling
 func (b *Builder) SnapshotBytes() ([]byte, error) {
-	b.lock.RLock()
-	data, err := json.Marshal(b.fullOrderBook)
-	b.lock.RUnlock()
+	data, err := json.Marshal(b.loadView().book)
 	if err != nil {
 		return nil, err
 	}
@@ -335,44 +773,181 @@ func (b *Builder) SnapshotBytes() ([]byte, error) {
 	return data, nil
 }
 
-func (b *Builder) GetPartOrderBook(number int) *exchanges.OrderBook {
+//BinarySnapshot is the full order book in the binary form of level3.EncodeBinary, Data is base64 in JSON
+type BinarySnapshot struct {
+	State          string `json:"state"`
+	Sequence       uint64 `json:"sequence"`
+	LastUpdateTime uint64 `json:"lastUpdateTime"`
+	Version        int    `json:"version"`
+	Data           []byte `json:"data"`
+}
+
+//GetL3BinarySnapshot encodes the published view of the full order book in the binary form,
+//along with an error wrapping exchanges.ErrOrderBookNotLive unless it is live
+func (b *Builder) GetL3BinarySnapshot() (*BinarySnapshot, error) {
+	view := b.loadView()
+	var buf bytes.Buffer
+	if err := view.book.EncodeBinary(&buf, view.orderBookTime); err != nil {
+		return nil, err
+	}
+
+	data := &BinarySnapshot{
+		State:          view.state.String(),
+		Sequence:       view.sequence,
+		LastUpdateTime: view.orderBookTime,
+		Version:        level3.BinaryVersion,
+		Data:           buf.Bytes(),
+	}
+
+	return data, view.checkLive(b.symbol)
+}
+
+//GetPartOrderBook returns the order book along with an error wrapping exchanges.ErrOrderBookNotLive unless it is live
+func (b *Builder) GetPartOrderBook(number int) (data *exchanges.OrderBook, err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			log.Error("GetPartOrderBook panic", zap.Any("r", r))
+			data, err = nil, errors.New("GetPartOrderBook panic")
 		}
 	}()
 
-	b.lock.RLock()
-	defer b.lock.RUnlock()
+	//the materialized best levels of the published view, or the full order book of the view for more levels
+	view := b.loadView()
+	asks, asksOk := view.partLevels(base.AskSide, number)
+	bids, bidsOk := view.partLevels(base.BidSide, number)
+	if !asksOk || !bidsOk {
+		asks = view.book.GetPartOrderBookBySide(base.AskSide, number)
+		bids = view.book.GetPartOrderBookBySide(base.BidSide, number)
+	}
+
+	data = &exchanges.OrderBook{
+		Asks:           asks,
+		Bids:           bids,
+		State:          view.state.String(),
+		Sequence:       view.sequence,
+		LastUpdateTime: view.orderBookTime,
+		Info: map[string]interface{}{
+			"time": view.orderBookTime,
+		},
+	}
+
+	return data, view.checkLive(b.symbol)
+}
+
+//GetAggregatedOrderBook returns up to number buckets of each side, each bucket is [price, size, count of orders]
+//of the orders with prices rounded to multiples of step, along with an error wrapping exchanges.ErrOrderBookNotLive unless it is live
+func (b *Builder) GetAggregatedOrderBook(number int, step string) (*exchanges.OrderBook, error) {
+	view := b.loadView()
+	stepValue, err := view.priceScale.Parse(step)
+	if err != nil || stepValue <= 0 {
+		return nil, errors.New("invalid step: " + step)
+	}
 
 	data := &exchanges.OrderBook{
-		Asks: b.fullOrderBook.GetPartOrderBookBySide(base.AskSide, number),
-		Bids: b.fullOrderBook.GetPartOrderBookBySide(base.BidSide, number),
+		Asks:           view.book.AggregateLevels(base.AskSide, stepValue, number),
+		Bids:           view.book.AggregateLevels(base.BidSide, stepValue, number),
+		State:          view.state.String(),
+		Sequence:       view.sequence,
+		LastUpdateTime: view.orderBookTime,
 		Info: map[string]interface{}{
-			"time": b.OrderBookTime,
+			"time": view.orderBookTime,
 		},
 	}
 
-	return data
+	return data, view.checkLive(b.symbol)
 }
 
-func (b *Builder) GetL3PartOrderBook(number int) *exchanges.Level3OrderBook {
+//GetQueuePosition returns the position of the order in the queue of its price level,
+//along with an error wrapping exchanges.ErrOrderBookNotLive unless the order book is live
+func (b *Builder) GetQueuePosition(orderId string) (*exchanges.QueuePosition, error) {
+	view := b.loadView()
+	order, position, ok := view.book.QueuePosition(orderId)
+	if !ok {
+		return nil, fmt.Errorf("%w, symbol: %s, orderId: %s, state: %s", exchanges.ErrOrderNotFound, b.symbol, orderId, view.state)
+	}
+
+	data := &exchanges.QueuePosition{
+		OrderId:     order.OrderId,
+		Side:        order.Side,
+		Price:       view.book.FormatPrice(order.Price),
+		Size:        view.book.FormatSize(order.Size),
+		OrdersAhead: position.OrdersAhead,
+		SizeAhead:   view.book.FormatSize(position.SizeAhead),
+		LevelCount:  position.LevelCount,
+		LevelSize:   view.book.FormatSize(position.LevelSize),
+		State:       view.state.String(),
+		Sequence:    view.sequence,
+	}
+
+	return data, view.checkLive(b.symbol)
+}
+
+//GetL3ExtendedPartOrderBook returns the orders with their times and fills, the age is the time since the order opened
+//until the latest message, along with an error wrapping exchanges.ErrOrderBookNotLive unless the order book is live
+func (b *Builder) GetL3ExtendedPartOrderBook(number int) (*exchanges.Level3ExtendedOrderBook, error) {
+	view := b.loadView()
+	data := &exchanges.Level3ExtendedOrderBook{
+		Asks:           view.extendedOrders(base.AskSide, number),
+		Bids:           view.extendedOrders(base.BidSide, number),
+		State:          view.state.String(),
+		Sequence:       view.sequence,
+		LastUpdateTime: view.orderBookTime,
+	}
+
+	return data, view.checkLive(b.symbol)
+}
+
+func (v *bookView) extendedOrders(side string, number int) []*exchanges.Level3Order {
+	orders := v.book.FrontOrders(side, number)
+	ret := make([]*exchanges.Level3Order, 0, len(orders))
+	for _, order := range orders {
+		var age uint64
+		if v.orderBookTime > order.Time {
+			age = v.orderBookTime - order.Time
+		}
+
+		ret = append(ret, &exchanges.Level3Order{
+			OrderId:    order.OrderId,
+			Price:      v.book.FormatPrice(order.Price),
+			Size:       v.book.FormatSize(order.Size),
+			Time:       order.Time,
+			UpdateTime: order.UpdateTime,
+			Fills:      order.Fills,
+			Age:        age,
+		})
+	}
+
+	return ret
+}
+
+//GetL3PartOrderBook returns the order book along with an error wrapping exchanges.ErrOrderBookNotLive unless it is live
+func (b *Builder) GetL3PartOrderBook(number int) (data *exchanges.Level3OrderBook, err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			log.Error("GetPartOrderBook panic", zap.Any("r", r))
+			log.Error("GetL3PartOrderBook panic", zap.Any("r", r))
+			data, err = nil, errors.New("GetL3PartOrderBook panic")
 		}
 	}()
 
-	b.lock.RLock()
-	defer b.lock.RUnlock()
+	//the materialized best orders of the published view, or the full order book of the view for more orders
+	view := b.loadView()
+	asks, asksOk := view.partOrders(base.AskSide, number)
+	bids, bidsOk := view.partOrders(base.BidSide, number)
+	if !asksOk || !bidsOk {
+		asks = view.book.GetL3PartOrderBookBySide(base.AskSide, number)
+		bids = view.book.GetL3PartOrderBookBySide(base.BidSide, number)
+	}
 
-	data := &exchanges.Level3OrderBook{
-		Asks: b.fullOrderBook.GetL3PartOrderBookBySide(base.AskSide, number),
-		Bids: b.fullOrderBook.GetL3PartOrderBookBySide(base.BidSide, number),
+	data = &exchanges.Level3OrderBook{
+		Asks:           asks,
+		Bids:           bids,
+		State:          view.state.String(),
+		Sequence:       view.sequence,
+		LastUpdateTime: view.orderBookTime,
 		Info: map[string]interface{}{
-			"time": b.OrderBookTime,
+			"time": view.orderBookTime,
 		},
 	}
 
-	return data
+	return data, view.checkLive(b.symbol)
 }
@@ -0,0 +1,431 @@
+package orderbook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/sdk"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/stream"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/services/log"
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	_ = log.SetLogger(zap.NewNop())
+	os.Exit(m.Run())
+}
+
+func newTestBuilder(window int, timeout time.Duration, sequence uint64) *Builder {
+	b := NewBuilder(nil, "KCS-USDT", Options{
+		ReorderWindow:  window,
+		ReorderTimeout: timeout,
+		PriceIncrement: "0.01",
+		SizeIncrement:  "0.0001",
+	})
+	b.resetOrderBook()
+	b.AddDepthToOrderBook(&DepthResponse{Sequence: sequence})
+	return b
+}
+
+func newTestMessage(t *testing.T, subject string, data map[string]interface{}) *stream.Event {
+	return newTestEvent(t, subject, data, false)
+}
+
+func newTestEvent(t *testing.T, subject string, data map[string]interface{}, future bool) *stream.Event {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := stream.NewEvent(&sdk.WebSocketDownstreamMessage{
+		Subject: subject,
+		RawData: raw,
+	}, future)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+func openMessage(t *testing.T, sequence uint64, orderId string, price string) *stream.Event {
+	return newTestMessage(t, stream.MessageOpenType, map[string]interface{}{
+		"sequence":  sequence,
+		"side":      stream.BuySide,
+		"orderId":   orderId,
+		"price":     price,
+		"size":      "1",
+		"orderTime": sequence,
+		"ts":        sequence,
+	})
+}
+
+func TestReorderHeldMessages(t *testing.T) {
+	b := newTestBuilder(10, time.Minute, 10)
+
+	for _, msg := range []*stream.Event{
+		openMessage(t, 11, "a", "1"),
+		openMessage(t, 13, "c", "3"),
+		openMessage(t, 14, "d", "4"),
+		openMessage(t, 12, "b", "2"),
+	} {
+		if err := b.updateFromStream(msg); err != nil {
+			t.Fatalf("updateFromStream error: %v", err)
+		}
+	}
+
+	if b.Sequence != 14 {
+		t.Errorf("Sequence should be 14, not %d", b.Sequence)
+	}
+	if b.reorder.len() != 0 {
+		t.Errorf("reorder buffer should be empty, not %d", b.reorder.len())
+	}
+	for _, orderId := range []string{"a", "b", "c", "d"} {
+		if b.fullOrderBook.GetOrder(orderId) == nil {
+			t.Errorf("order %s should be in the order book", orderId)
+		}
+	}
+}
+
+func TestReorderDuplicateMessages(t *testing.T) {
+	b := newTestBuilder(10, time.Minute, 10)
+
+	for _, msg := range []*stream.Event{
+		openMessage(t, 11, "a", "1"),
+		openMessage(t, 13, "c", "3"),
+		openMessage(t, 11, "a", "1"),
+		openMessage(t, 13, "c", "3"),
+		openMessage(t, 12, "b", "2"),
+		openMessage(t, 12, "b", "2"),
+	} {
+		if err := b.updateFromStream(msg); err != nil {
+			t.Fatalf("updateFromStream error: %v", err)
+		}
+	}
+
+	if b.Sequence != 13 {
+		t.Errorf("Sequence should be 13, not %d", b.Sequence)
+	}
+	if bids := b.loadView().book.GetL3PartOrderBookBySide("bids", 0); len(bids) != 3 {
+		t.Errorf("order book should have 3 bids, not %d", len(bids))
+	}
+}
+
+func TestReorderWindowOverflow(t *testing.T) {
+	b := newTestBuilder(2, time.Minute, 10)
+
+	for _, sequence := range []uint64{12, 13} {
+		if err := b.updateFromStream(openMessage(t, sequence, "a", "1")); err != nil {
+			t.Fatalf("updateFromStream error: %v", err)
+		}
+	}
+
+	err := b.updateFromStream(openMessage(t, 14, "b", "1"))
+	var gapErr *SequenceGapError
+	if !errors.As(err, &gapErr) {
+		t.Fatalf("updateFromStream should return a SequenceGapError, not %v", err)
+	}
+	if gapErr.Sequence != 10 || gapErr.MsgSequence != 12 || gapErr.Size() != 1 {
+		t.Errorf("unexpected gap: %d => %d, size %d", gapErr.Sequence, gapErr.MsgSequence, gapErr.Size())
+	}
+}
+
+func TestReorderTimeout(t *testing.T) {
+	b := newTestBuilder(10, time.Millisecond, 10)
+
+	if err := b.updateFromStream(openMessage(t, 12, "a", "1")); err != nil {
+		t.Fatalf("updateFromStream error: %v", err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	err := b.updateFromStream(openMessage(t, 13, "b", "1"))
+	var gapErr *SequenceGapError
+	if !errors.As(err, &gapErr) {
+		t.Fatalf("updateFromStream should return a SequenceGapError, not %v", err)
+	}
+}
+
+//TestReorderTimeoutWithoutMessages checks the held messages expire on the deadline, though no other message arrives
+func TestReorderTimeoutWithoutMessages(t *testing.T) {
+	b := NewBuilder(nil, "KCS-USDT", Options{ReorderWindow: 10, ReorderTimeout: 20 * time.Millisecond, PriceIncrement: "0.01", SizeIncrement: "0.0001"})
+	b.fetchSnapshot = func() (*DepthResponse, error) {
+		return snapshotOf(2, "a"), nil
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.ReloadOrderBook()
+	}()
+
+	for sequence := uint64(2); sequence <= 7; sequence++ {
+		b.Messages <- openMessage(t, sequence, "abcdef"[sequence-2:sequence-1], strconv.FormatUint(sequence, 10))
+	}
+	waitState(t, b, StateLive)
+
+	b.Messages <- openMessage(t, 9, "h", "9")
+	waitState(t, b, StateResyncing)
+	if gaps := b.SyncStats().Gaps; gaps != 1 {
+		t.Errorf("the expired gap should be counted, got %d", gaps)
+	}
+
+	close(b.Messages)
+	<-done
+}
+
+func TestReorderDisabled(t *testing.T) {
+	b := newTestBuilder(0, time.Minute, 10)
+
+	if err := b.updateFromStream(openMessage(t, 12, "a", "1")); err == nil {
+		t.Errorf("updateFromStream should fail on any gap when the reorder window is 0")
+	}
+}
+
+func TestL3ExtendedPartOrderBook(t *testing.T) {
+	b := newTestBuilder(10, time.Minute, 10)
+	b.setState(StateLive)
+
+	for _, msg := range []*stream.Event{
+		openMessage(t, 11, "a", "1"),
+		openMessage(t, 12, "b", "1"),
+		newTestMessage(t, stream.MessageMatchType, map[string]interface{}{
+			"sequence": 13, "side": stream.SellSide, "price": "1", "size": "0.25", "remainSize": "0.75",
+			"takerOrderId": "taker", "makerOrderId": "a", "tradeId": "trade", "ts": 13,
+		}),
+		newTestMessage(t, stream.MessageUpdateType, map[string]interface{}{
+			"sequence": 14, "orderId": "b", "size": "0.5", "ts": 14,
+		}),
+	} {
+		if err := b.updateFromStream(msg); err != nil {
+			t.Fatalf("updateFromStream error: %v", err)
+		}
+	}
+
+	data, err := b.GetL3ExtendedPartOrderBook(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []exchanges.Level3Order{
+		{OrderId: "a", Price: "1", Size: "0.75", Time: 11, UpdateTime: 13, Fills: 1, Age: 3},
+		{OrderId: "b", Price: "1", Size: "0.5", Time: 12, UpdateTime: 14, Fills: 0, Age: 2},
+	}
+	if len(data.Bids) != len(want) || len(data.Asks) != 0 {
+		t.Fatalf("unexpected order book: %+v", data)
+	}
+	for i, order := range data.Bids {
+		if *order != want[i] {
+			t.Errorf("got %+v, want %+v", *order, want[i])
+		}
+	}
+}
+
+//TestQueuePriorityMatchesSnapshot checks the orders are queued by orderTime, not by the ts of the messages,
+//so the order book matches a snapshot of the exchange order by order
+func TestQueuePriorityMatchesSnapshot(t *testing.T) {
+	b := newTestBuilder(10, time.Minute, 10)
+	b.resetOrderBook()
+	b.AddDepthToOrderBook(&DepthResponse{
+		Sequence: 10,
+		Bids: [][4]interface{}{
+			{"s1", "1", "1", json.Number("100")},
+			{"s2", "1", "1", json.Number("300")},
+		},
+	})
+
+	for _, o := range []struct {
+		orderId   string
+		orderTime uint64
+	}{{"a", 200}, {"b", 300}, {"c", 300}, {"d", 50}} {
+		sequence := b.Sequence + 1
+		msg := newTestMessage(t, stream.MessageOpenType, map[string]interface{}{
+			"sequence":  sequence,
+			"side":      stream.BuySide,
+			"orderId":   o.orderId,
+			"price":     "1",
+			"size":      "1",
+			"orderTime": o.orderTime,
+			"ts":        1000 + sequence,
+		})
+		if err := b.updateFromStream(msg); err != nil {
+			t.Fatalf("updateFromStream error: %v", err)
+		}
+	}
+
+	got, err := b.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := b.DepthResponse2FullOrderBook(&DepthResponse{
+		Sequence: 14,
+		Bids: [][4]interface{}{
+			{"d", "1", "1", json.Number("50")},
+			{"s1", "1", "1", json.Number("100")},
+			{"a", "1", "1", json.Number("200")},
+			{"s2", "1", "1", json.Number("300")},
+			{"b", "1", "1", json.Number("300")},
+			{"c", "1", "1", json.Number("300")},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Bids, want.Bids) {
+		t.Errorf("unexpected queue\ngot:  %v\nwant: %v", got.Bids, want.Bids)
+	}
+}
+
+//snapshotOf returns the snapshot of the orders opened by openMessage up to the sequence
+func snapshotOf(sequence uint64, orderIds string) *DepthResponse {
+	depth := &DepthResponse{Sequence: sequence, Asks: [][4]interface{}{}}
+	for i, orderId := range orderIds {
+		s := strconv.Itoa(i + 2)
+		depth.Bids = append(depth.Bids, [4]interface{}{string(orderId), s, "1", json.Number(s)})
+	}
+	return depth
+}
+
+func waitState(t *testing.T, b *Builder, want State) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for state, _ := b.Status(); state != want; state, _ = b.Status() {
+		if time.Now().After(deadline) {
+			t.Fatalf("the order book should be %s, got %s", want, state)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestResyncFromSnapshot(t *testing.T) {
+	b := NewBuilder(nil, "KCS-USDT", Options{PriceIncrement: "0.01", SizeIncrement: "0.0001"})
+	snapshots := make(chan *DepthResponse, 1)
+	b.fetchSnapshot = func() (*DepthResponse, error) {
+		return <-snapshots, nil
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.ReloadOrderBook()
+	}()
+
+	//order i opened at sequence i+2 of the price i+2
+	const orderIds = "abcdefghijklm"
+	var messages []*stream.Event
+	for sequence := uint64(2); sequence <= 14; sequence++ {
+		messages = append(messages, openMessage(t, sequence, orderIds[sequence-2:sequence-1], strconv.FormatUint(sequence, 10)))
+	}
+	send := func(from, to uint64) {
+		for _, msg := range messages[from-2 : to-1] {
+			b.Messages <- msg
+		}
+	}
+
+	//the snapshot is fetched once the stream is buffered, the buffer is replayed from its sequence
+	snapshots <- snapshotOf(3, orderIds[:2])
+	send(2, 7)
+	waitState(t, b, StateLive)
+
+	//sequence 8 is missed, the message after the gap starts a new playback
+	send(9, 9)
+	waitState(t, b, StateResyncing)
+	snapshots <- snapshotOf(10, orderIds[:9])
+	send(10, 14)
+	waitState(t, b, StateLive)
+
+	if _, sequence := b.Status(); sequence != 14 || b.SyncStats().Resyncs != 1 {
+		t.Errorf("the order book should resync once up to 14, sequence: %d, resyncs: %d", sequence, b.SyncStats().Resyncs)
+	}
+	got, err := b.SnapshotBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := newBusyBuilder(t, messages).SnapshotBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("the resynced order book should hold the missed order\ngot:  %s\nwant: %s", got, want)
+	}
+
+	close(b.Messages)
+	<-done
+}
+
+//TestExtraDecimals feeds a snapshot price and a stream size finer than the increments,
+//the order book is built in the decimals of the snapshot, and resynced in the decimals of the stream
+func TestExtraDecimals(t *testing.T) {
+	b := NewBuilder(nil, "KCS-USDT", Options{PriceIncrement: "0.01", SizeIncrement: "0.0001"})
+	snapshots := make(chan *DepthResponse, 1)
+	b.fetchSnapshot = func() (*DepthResponse, error) {
+		return <-snapshots, nil
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.ReloadOrderBook()
+	}()
+
+	//order i opened at sequence i+2 of the price i+2, but the first one of 1.005
+	const orderIds = "abcdefghijkl"
+	send := func(from, to uint64) {
+		for sequence := from; sequence <= to; sequence++ {
+			price := strconv.FormatUint(sequence, 10)
+			if sequence == 2 {
+				price = "1.005"
+			}
+			b.Messages <- openMessage(t, sequence, orderIds[sequence-2:sequence-1], price)
+		}
+	}
+	check := func(want [][3]string) {
+		t.Helper()
+		data, err := b.GetL3PartOrderBook(3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(data.Bids, want) {
+			t.Errorf("bids should be %v, got %v", want, data.Bids)
+		}
+	}
+
+	snapshots <- &DepthResponse{Sequence: 3, Asks: [][4]interface{}{}, Bids: [][4]interface{}{
+		{"b", "3", "1", json.Number("3")},
+		{"a", "1.005", "1", json.Number("2")},
+	}}
+	send(2, 7)
+	waitState(t, b, StateLive)
+	check([][3]string{{"f", "7", "1"}, {"e", "6", "1"}, {"d", "5", "1"}})
+
+	//the size of 5 decimals starts a new playback in the wider scale
+	b.Messages <- newTestMessage(t, stream.MessageOpenType, map[string]interface{}{
+		"sequence":  8,
+		"side":      stream.BuySide,
+		"orderId":   "g",
+		"price":     "9.5",
+		"size":      "0.00001",
+		"orderTime": 8,
+		"ts":        8,
+	})
+	waitState(t, b, StateResyncing)
+	snapshots <- &DepthResponse{Sequence: 8, Asks: [][4]interface{}{}, Bids: [][4]interface{}{
+		{"g", "9.5", "0.00001", json.Number("8")},
+		{"f", "7", "1", json.Number("7")},
+		{"a", "1.005", "1", json.Number("2")},
+	}}
+	send(9, 13)
+	waitState(t, b, StateLive)
+	check([][3]string{{"l", "13", "1"}, {"k", "12", "1"}, {"j", "11", "1"}})
+	if data, err := b.GetL3PartOrderBook(0); err != nil || len(data.Bids) != 8 || data.Bids[4] != [3]string{"g", "9.5", "0.00001"} {
+		t.Errorf("the order of 5 decimals should be resting, got %v, %v", data, err)
+	}
+	if b.priceScale.Decimals != 3 || b.sizeScale.Decimals != 5 || b.priceTick != 10 {
+		t.Errorf("the scales should be widened to 3 and 5 decimals, got %d, %d, tick %d", b.priceScale.Decimals, b.sizeScale.Decimals, b.priceTick)
+	}
+
+	close(b.Messages)
+	<-done
+}
@@ -0,0 +1,109 @@
+package orderbook
+
+import (
+	"errors"
+	"math"
+
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/base"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/level3"
+)
+
+//GetOrderBookRange returns the price levels selected by the query,
+//along with an error wrapping exchanges.ErrOrderBookNotLive unless the order book is live
+func (b *Builder) GetOrderBookRange(query *exchanges.BookRangeQuery) (*exchanges.OrderBook, error) {
+	view := b.loadView()
+	asks, bids, err := selectLevels(view.book, query)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &exchanges.OrderBook{
+		Asks:           view.book.FormatLevels(asks),
+		Bids:           view.book.FormatLevels(bids),
+		State:          view.state.String(),
+		Sequence:       view.sequence,
+		LastUpdateTime: view.orderBookTime,
+		Info: map[string]interface{}{
+			"time": view.orderBookTime,
+		},
+	}
+
+	return data, view.checkLive(b.symbol)
+}
+
+//GetL3OrderBookRange returns the orders of the price levels selected by the query,
+//along with an error wrapping exchanges.ErrOrderBookNotLive unless the order book is live
+func (b *Builder) GetL3OrderBookRange(query *exchanges.BookRangeQuery) (*exchanges.Level3OrderBook, error) {
+	view := b.loadView()
+	asks, bids, err := selectLevels(view.book, query)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &exchanges.Level3OrderBook{
+		Asks:           view.book.FormatLevelOrders(asks),
+		Bids:           view.book.FormatLevelOrders(bids),
+		State:          view.state.String(),
+		Sequence:       view.sequence,
+		LastUpdateTime: view.orderBookTime,
+		Info: map[string]interface{}{
+			"time": view.orderBookTime,
+		},
+	}
+
+	return data, view.checkLive(b.symbol)
+}
+
+func selectLevels(ob *level3.View, query *exchanges.BookRangeQuery) (asks, bids []*level3.LevelView, err error) {
+	byPrice := query.MinPrice != "" || query.MaxPrice != ""
+	bySize := query.Size != ""
+	byBps := query.Bps != 0
+
+	modes := 0
+	for _, ok := range []bool{byPrice, bySize, byBps} {
+		if ok {
+			modes++
+		}
+	}
+	if modes != 1 {
+		return nil, nil, errors.New("one of minPrice/maxPrice, size or bps is required")
+	}
+
+	switch {
+	case byPrice:
+		low, high := int64(0), int64(math.MaxInt64-1)
+		if query.MinPrice != "" {
+			if low, err = ob.PriceScale.Parse(query.MinPrice); err != nil {
+				return nil, nil, errors.New("invalid minPrice: " + query.MinPrice)
+			}
+		}
+		if query.MaxPrice != "" {
+			if high, err = ob.PriceScale.Parse(query.MaxPrice); err != nil {
+				return nil, nil, errors.New("invalid maxPrice: " + query.MaxPrice)
+			}
+		}
+		return ob.LevelsBetween(base.AskSide, low, high), ob.LevelsBetween(base.BidSide, low, high), nil
+
+	case bySize:
+		size, err := ob.SizeScale.Parse(query.Size)
+		if err != nil || size <= 0 {
+			return nil, nil, errors.New("invalid size: " + query.Size)
+		}
+		return ob.LevelsCovering(base.AskSide, size), ob.LevelsCovering(base.BidSide, size), nil
+	}
+
+	if query.Bps < 0 || query.Bps >= 10000 {
+		return nil, nil, errors.New("bps should be between 1 and 9999")
+	}
+
+	ask, bid := bestLevel(ob, base.AskSide), bestLevel(ob, base.BidSide)
+	if ask == nil || bid == nil {
+		return nil, nil, errors.New("no mid price, a side of the order book is empty")
+	}
+
+	mid2 := ask.Price + bid.Price
+	askLimit := mulDiv(mid2, int64(10000+query.Bps), 20000, false)
+	bidLimit := mulDiv(mid2, int64(10000-query.Bps), 20000, true)
+	return ob.LevelsBetween(base.AskSide, ask.Price, askLimit), ob.LevelsBetween(base.BidSide, bidLimit, bid.Price), nil
+}
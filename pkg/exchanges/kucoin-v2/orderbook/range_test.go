@@ -0,0 +1,70 @@
+package orderbook
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/base"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/fixed"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/level3"
+)
+
+func TestSelectLevels(t *testing.T) {
+	price, _ := fixed.NewScale(2)
+	size, _ := fixed.NewScale(4)
+	ob := level3.NewOrderBook(price, size)
+	for i, o := range [][3]string{
+		{base.AskSide, "100.5", "1"},
+		{base.AskSide, "101", "2"},
+		{base.AskSide, "102", "3"},
+		{base.BidSide, "99.5", "4"},
+		{base.BidSide, "99", "5"},
+		{base.BidSide, "97", "6"},
+	} {
+		order, err := ob.ParseOrder(string(rune('a'+i)), o[0], o[1], o[2], uint64(i), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ob.AddOrder(order); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	view := ob.View()
+
+	tests := []struct {
+		query      exchanges.BookRangeQuery
+		asks, bids [][2]string
+	}{
+		{exchanges.BookRangeQuery{MinPrice: "99", MaxPrice: "101"}, [][2]string{{"100.5", "1"}, {"101", "2"}}, [][2]string{{"99.5", "4"}, {"99", "5"}}},
+		{exchanges.BookRangeQuery{MaxPrice: "99"}, [][2]string{}, [][2]string{{"99", "5"}, {"97", "6"}}},
+		{exchanges.BookRangeQuery{Size: "3"}, [][2]string{{"100.5", "1"}, {"101", "2"}}, [][2]string{{"99.5", "4"}}},
+		//the mid is 100, 100 bps is between 99 and 101
+		{exchanges.BookRangeQuery{Bps: 100}, [][2]string{{"100.5", "1"}, {"101", "2"}}, [][2]string{{"99.5", "4"}, {"99", "5"}}},
+	}
+	for _, test := range tests {
+		asks, bids, err := selectLevels(view, &test.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := view.FormatLevels(asks); !reflect.DeepEqual(got, test.asks) {
+			t.Errorf("%+v: unexpected asks %v", test.query, got)
+		}
+		if got := view.FormatLevels(bids); !reflect.DeepEqual(got, test.bids) {
+			t.Errorf("%+v: unexpected bids %v", test.query, got)
+		}
+	}
+
+	for _, query := range []exchanges.BookRangeQuery{
+		{},
+		{MinPrice: "99", Size: "1"},
+		{Size: "0"},
+		{MinPrice: "99.001"},
+		{Bps: 10000},
+	} {
+		if _, _, err := selectLevels(view, &query); err == nil {
+			t.Errorf("%+v should be invalid", query)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package orderbook
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/stream"
+)
+
+//SequenceGapError is returned when the messages between Sequence and MsgSequence are missing
+type SequenceGapError struct {
+	Sequence    uint64
+	MsgSequence uint64
+	ChanLen     int
+}
+
+func (e *SequenceGapError) Error() string {
+	return fmt.Sprintf(
+		"currentSequence: %d, msgSequence: %d, the sequence is not continuous, current chanLen: %d",
+		e.Sequence,
+		e.MsgSequence,
+		e.ChanLen,
+	)
+}
+
+//Size returns the number of missing messages
+func (e *SequenceGapError) Size() uint64 {
+	return e.MsgSequence - e.Sequence - 1
+}
+
+//reorderBuffer holds the messages arriving before their previous sequence,
+//until the missing sequence arrives, the window overflows or the timeout expires
+type reorderBuffer struct {
+	window   int
+	timeout  time.Duration
+	messages map[uint64]*stream.Event
+	first    uint64    //lowest held sequence
+	since    time.Time //when the current gap was opened
+}
+
+func newReorderBuffer(window int, timeout time.Duration) *reorderBuffer {
+	return &reorderBuffer{
+		window:   window,
+		timeout:  timeout,
+		messages: make(map[uint64]*stream.Event),
+	}
+}
+
+func (r *reorderBuffer) len() int {
+	return len(r.messages)
+}
+
+func (r *reorderBuffer) add(msg *stream.Event, now time.Time) {
+	if len(r.messages) == 0 {
+		r.since = now
+	}
+	if len(r.messages) == 0 || msg.Sequence < r.first {
+		r.first = msg.Sequence
+	}
+
+	r.messages[msg.Sequence] = msg
+}
+
+//pop removes and returns the held message of the sequence, nil if it has not arrived
+func (r *reorderBuffer) pop(sequence uint64, now time.Time) *stream.Event {
+	msg, ok := r.messages[sequence]
+	if !ok {
+		return nil
+	}
+
+	delete(r.messages, sequence)
+	if len(r.messages) > 0 {
+		//a newer gap is opened now
+		r.since = now
+		r.first = sequence + 1
+		for _, ok := r.messages[r.first]; !ok; _, ok = r.messages[r.first] {
+			r.first++
+		}
+	}
+
+	return msg
+}
+
+//deadline returns when the held messages expire, ok is false if no message is held
+func (r *reorderBuffer) deadline() (deadline time.Time, ok bool) {
+	if len(r.messages) == 0 {
+		return time.Time{}, false
+	}
+
+	return r.since.Add(r.timeout), true
+}
+
+//expired reports whether the gap should be recovered by a resync
+func (r *reorderBuffer) expired(now time.Time) bool {
+	if len(r.messages) == 0 {
+		return false
+	}
+
+	return len(r.messages) > r.window || now.Sub(r.since) > r.timeout
+}
+
+func (r *reorderBuffer) reset() {
+	r.messages = make(map[uint64]*stream.Event)
+	r.first = 0
+}
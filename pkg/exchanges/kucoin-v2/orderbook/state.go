@@ -0,0 +1,32 @@
+package orderbook
+
+//State is the lifecycle state of an order book
+type State int32
+
+const (
+	StateInitializing State = iota //waiting for the first messages
+	StatePlayback                  //buffering the stream and replaying it on the first snapshot
+	StateLive                      //the order book is synced with the stream
+	StateResyncing                 //rebuilding the order book from a new snapshot, after a gap or a reconnect
+	StateStale                     //no message received for a while
+	StateFailed                    //the snapshot could not be fetched or replayed, retrying
+)
+
+func (s State) String() string {
+	switch s {
+	case StateInitializing:
+		return "initializing"
+	case StatePlayback:
+		return "playback"
+	case StateLive:
+		return "live"
+	case StateResyncing:
+		return "resyncing"
+	case StateStale:
+		return "stale"
+	case StateFailed:
+		return "failed"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,91 @@
+package orderbook
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges"
+)
+
+//checkNotLive checks the readers return the order book with its state along with ErrOrderBookNotLive
+func checkNotLive(t *testing.T, b *Builder, state State) {
+	t.Helper()
+	data, err := b.GetPartOrderBook(0)
+	if !errors.Is(err, exchanges.ErrOrderBookNotLive) {
+		t.Fatalf("%s: unexpected error %v", state, err)
+	}
+	if data == nil || data.State != state.String() {
+		t.Errorf("%s: the data should have the state, got %+v", state, data)
+	}
+}
+
+//TestStateTransitions walks the order book through
+//initializing => playback => failed => live => stale => live => resyncing => live
+func TestStateTransitions(t *testing.T) {
+	b := NewBuilder(nil, "KCS-USDT", Options{PriceIncrement: "0.01", SizeIncrement: "0.0001"})
+	snapshots := make(chan *DepthResponse, 1)
+	b.fetchSnapshot = func() (*DepthResponse, error) {
+		snapshot := <-snapshots
+		if snapshot == nil {
+			return nil, errors.New("fetch failed")
+		}
+		return snapshot, nil
+	}
+	checkNotLive(t, b, StateInitializing)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.ReloadOrderBook()
+	}()
+
+	const orderIds = "abcdefghijklmno"
+	send := func(from, to uint64) {
+		for sequence := from; sequence <= to; sequence++ {
+			b.Messages <- openMessage(t, sequence, orderIds[sequence-2:sequence-1], strconv.FormatUint(sequence, 10))
+		}
+	}
+
+	send(2, 2)
+	waitState(t, b, StatePlayback)
+	checkNotLive(t, b, StatePlayback)
+
+	//the snapshot is fetched once the stream is buffered, it is fetched again a second after the failure
+	snapshots <- nil
+	send(3, 7)
+	waitState(t, b, StateFailed)
+	checkNotLive(t, b, StateFailed)
+
+	time.Sleep(time.Second)
+	snapshots <- snapshotOf(3, orderIds[:2])
+	send(8, 8)
+	waitState(t, b, StateLive)
+	if _, err := b.GetPartOrderBook(0); err != nil {
+		t.Fatal(err)
+	}
+
+	if !b.MarkStale() {
+		t.Fatal("a live order book should be marked stale")
+	}
+	checkNotLive(t, b, StateStale)
+	send(9, 9)
+	waitState(t, b, StateLive)
+
+	b.Resync()
+	checkNotLive(t, b, StateResyncing)
+	if b.MarkStale() {
+		t.Errorf("an order book not live should not be marked stale")
+	}
+	//the message seeing the resync is dropped, the playback buffers the stream from the next one
+	snapshots <- snapshotOf(11, orderIds[:10])
+	send(10, 16)
+	waitState(t, b, StateLive)
+	if _, sequence := b.Status(); sequence != 16 {
+		t.Errorf("the order book should be resynced up to 16, got %d", sequence)
+	}
+
+	close(b.Messages)
+	<-done
+}
@@ -0,0 +1,34 @@
+package orderbook
+
+import "time"
+
+const maxRecentGaps = 20
+
+//SyncStats reports the feed quality of an order book
+type SyncStats struct {
+	Resyncs     uint64   `json:"resyncs"`     //times the order book was rebuilt from a snapshot
+	Gaps        uint64   `json:"gaps"`        //sequence gaps seen
+	GapSizeSum  uint64   `json:"gapSizeSum"`  //missing messages of all gaps
+	GapSizeMax  uint64   `json:"gapSizeMax"`  //missing messages of the largest gap
+	RecentGaps  []uint64 `json:"recentGaps"`  //missing messages of the latest gaps, oldest first
+	LastGapTime int64    `json:"lastGapTime"` //unix nano
+}
+
+func (s *SyncStats) addGap(size uint64) {
+	s.Gaps++
+	s.GapSizeSum += size
+	if size > s.GapSizeMax {
+		s.GapSizeMax = size
+	}
+
+	s.RecentGaps = append(s.RecentGaps, size)
+	if len(s.RecentGaps) > maxRecentGaps {
+		s.RecentGaps = s.RecentGaps[len(s.RecentGaps)-maxRecentGaps:]
+	}
+	s.LastGapTime = time.Now().UnixNano()
+}
+
+func (s SyncStats) copy() SyncStats {
+	s.RecentGaps = append([]uint64{}, s.RecentGaps...)
+	return s
+}
@@ -0,0 +1,210 @@
+package orderbook
+
+import (
+	"time"
+
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/stream"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/base"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/fixed"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/level3"
+)
+
+const defaultViewDepth = 50
+
+//bookView is an immutable view of the order book published by the writer after every update,
+//readers load it from an atomic pointer without taking the lock
+type bookView struct {
+	state         State
+	sequence      uint64
+	orderBookTime uint64
+	priceScale    fixed.Scale
+	sizeScale     fixed.Scale
+	priceTick     int64
+
+	//the increments of the scales and the multiplier of a futures lot
+	priceIncrement string
+	sizeIncrement  string
+	multiplier     string
+
+	//the full order book, its price levels are copied only when they change, so publishing it is cheap
+	book  *level3.View
+	stats *bookStats
+
+	asks, bids *topSide
+}
+
+//topSide materializes the best levels and orders of a side, it is never modified once published
+type topSide struct {
+	levels    [][2]int64 //price, size
+	orders    []topOrder
+	truncated bool //more orders than depth within the levels walked
+
+	//the walk stopped at the level of window, the levels beyond it are not materialized,
+	//unbounded when all levels are walked
+	window  int64
+	bounded bool
+}
+
+type topOrder struct {
+	orderId string
+	price   int64
+	size    int64
+}
+
+//newTopSide walks the best depth levels of the side, and the best depth orders within them
+func newTopSide(book *level3.View, side string, depth int) *topSide {
+	s := &topSide{
+		levels: make([][2]int64, 0, depth),
+		orders: make([]topOrder, 0, depth),
+	}
+	book.RangeLevels(side, func(level *level3.LevelView) bool {
+		s.levels = append(s.levels, [2]int64{level.Price, level.Size})
+		level.RangeOrders(func(order *level3.Order) bool {
+			if len(s.orders) >= depth {
+				s.truncated = true
+				return false
+			}
+			s.orders = append(s.orders, topOrder{orderId: order.OrderId, price: order.Price, size: order.Size})
+			return true
+		})
+
+		if len(s.levels) >= depth {
+			s.window, s.bounded = level.Price, true
+			return false
+		}
+		return true
+	})
+
+	return s
+}
+
+//changedWithin reports whether the side of the order book is changed at the prices up to window since ResetChanges
+func changedWithin(ob *level3.OrderBook, side string, window int64, bounded bool) bool {
+	price, changed := ob.BestChange(side)
+	if !changed {
+		return false
+	}
+	if !bounded {
+		return true
+	}
+
+	if side == base.AskSide {
+		return price <= window
+	}
+	return price >= window
+}
+
+//publish refreshes the stats and publishes a new view of the order book, the caller must hold the lock,
+//the materialized levels and the stats are reused unless the order book is changed within them
+func (b *Builder) publish() {
+	ob := b.fullOrderBook
+	book := ob.View()
+	priceTick := b.priceTick
+	if ob.PriceScale != b.priceScale {
+		//the tick is in the wider scale of the next order book
+		priceTick = 0
+	}
+
+	view := &bookView{
+		state:         b.state,
+		sequence:      b.Sequence,
+		orderBookTime: b.OrderBookTime,
+		priceScale:    ob.PriceScale,
+		sizeScale:     ob.SizeScale,
+		priceTick:     priceTick,
+
+		priceIncrement: b.priceIncrement,
+		sizeIncrement:  b.sizeIncrement,
+		multiplier:     b.multiplier,
+		book:           book,
+	}
+
+	last, _ := b.view.Load().(*bookView)
+	if b.stats.refresh(ob, book) || last == nil {
+		//the refresh allocates new slices, so the copy is never changed
+		stats := *b.stats
+		view.stats = &stats
+	} else {
+		view.stats = last.stats
+	}
+	if last != nil && b.viewBook == ob && !changedWithin(ob, base.AskSide, last.asks.window, last.asks.bounded) {
+		view.asks = last.asks
+	} else {
+		view.asks = newTopSide(book, base.AskSide, b.viewDepth)
+	}
+	if last != nil && b.viewBook == ob && !changedWithin(ob, base.BidSide, last.bids.window, last.bids.bounded) {
+		view.bids = last.bids
+	} else {
+		view.bids = newTopSide(book, base.BidSide, b.viewDepth)
+	}
+
+	b.viewBook = ob
+	ob.ResetChanges()
+	b.view.Store(view)
+}
+
+func (b *Builder) loadView() *bookView {
+	return b.view.Load().(*bookView)
+}
+
+func (v *bookView) checkLive(symbol string) error {
+	return checkLive(symbol, v.state)
+}
+
+//partLevels returns up to number levels of the side, ok is false if the view has less levels than asked
+func (v *bookView) partLevels(side string, number int) (arr [][2]string, ok bool) {
+	s := v.side(side)
+	if number <= 0 || number > len(s.levels) && s.bounded {
+		return nil, false
+	}
+
+	number = base.Min(number, len(s.levels))
+	arr = make([][2]string, 0, number)
+	for _, level := range s.levels[:number] {
+		arr = append(arr, [2]string{v.priceScale.Format(level[0]), v.sizeScale.Format(level[1])})
+	}
+
+	return arr, true
+}
+
+//partOrders returns up to number orders of the side, ok is false if the view has less orders than asked
+func (v *bookView) partOrders(side string, number int) (arr [][3]string, ok bool) {
+	s := v.side(side)
+	if number <= 0 || number > len(s.orders) && (s.bounded || s.truncated) {
+		return nil, false
+	}
+
+	number = base.Min(number, len(s.orders))
+	arr = make([][3]string, 0, number)
+	for _, order := range s.orders[:number] {
+		arr = append(arr, [3]string{order.orderId, v.priceScale.Format(order.price), v.sizeScale.Format(order.size)})
+	}
+
+	return arr, true
+}
+
+func (v *bookView) side(side string) *topSide {
+	if side == base.AskSide {
+		return v.asks
+	}
+
+	return v.bids
+}
+
+//nextMessage waits for the next message of the writer, it returns a nil message on the deadline of the held messages,
+//so they expire without another message arriving
+func (b *Builder) nextMessage() (*stream.Event, bool) {
+	var expire <-chan time.Time
+	if deadline, ok := b.reorder.deadline(); ok {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		expire = timer.C
+	}
+
+	select {
+	case msg, ok := <-b.Messages:
+		return msg, ok
+	case <-expire:
+		return nil, true
+	}
+}
@@ -0,0 +1,152 @@
+package orderbook
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/base"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/level3"
+)
+
+//walkOrderBook returns up to number levels and orders of the side walked from the order book itself, all if number is 0
+func walkOrderBook(ob *level3.OrderBook, side string, number int) (levels [][2]string, orders [][3]string) {
+	list := ob.Asks
+	if side == base.BidSide {
+		list = ob.Bids
+	}
+
+	levels, orders = [][2]string{}, [][3]string{}
+	for it := list.Iterator(); it.Next(); {
+		level := it.Value()
+		if number == 0 || len(levels) < number {
+			levels = append(levels, [2]string{ob.FormatPrice(level.Price), ob.FormatSize(level.Size)})
+		}
+		for order := level.Front(); order != nil; order = order.Next() {
+			if number == 0 || len(orders) < number {
+				orders = append(orders, [3]string{order.OrderId, ob.FormatPrice(order.Price), ob.FormatSize(order.Size)})
+			}
+		}
+	}
+
+	return levels, orders
+}
+
+//TestViewMatchesOrderBook checks the published view skipping the changes beyond its levels
+//is the same as the order book after every message
+func TestViewMatchesOrderBook(t *testing.T) {
+	b := NewBuilder(nil, "KCS-USDT", Options{PriceIncrement: "0.01", SizeIncrement: "0.0001", ViewDepth: 5})
+	b.resetOrderBook()
+	b.AddDepthToOrderBook(&DepthResponse{Sequence: 1})
+	b.setState(StateLive)
+
+	for _, msg := range readBusyStream(t, 3000) {
+		if err := b.updateFromStream(msg); err != nil {
+			t.Fatal(err)
+		}
+
+		//1 and 5 are served by the materialized levels, 0 and 8 by the full order book of the view
+		numbers := []int{1, 5}
+		if msg.Sequence%100 == 0 {
+			numbers = append(numbers, 0, 8)
+		}
+		for _, number := range numbers {
+			l2, err := b.GetPartOrderBook(number)
+			if err != nil {
+				t.Fatal(err)
+			}
+			l3, err := b.GetL3PartOrderBook(number)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			askLevels, askOrders := walkOrderBook(b.fullOrderBook, base.AskSide, number)
+			bidLevels, bidOrders := walkOrderBook(b.fullOrderBook, base.BidSide, number)
+			if !reflect.DeepEqual(l2.Asks, askLevels) || !reflect.DeepEqual(l2.Bids, bidLevels) {
+				t.Fatalf("sequence %d, number %d: unexpected level2 %v", msg.Sequence, number, l2)
+			}
+			if !reflect.DeepEqual(l3.Asks, askOrders) || !reflect.DeepEqual(l3.Bids, bidOrders) {
+				t.Fatalf("sequence %d, number %d: unexpected level3 %v", msg.Sequence, number, l3)
+			}
+			if l2.Sequence != msg.Sequence || l3.Sequence != msg.Sequence {
+				t.Fatalf("sequence %d: unexpected view sequence %d, %d", msg.Sequence, l2.Sequence, l3.Sequence)
+			}
+		}
+	}
+}
+
+//TestViewUnchangedByWriter reads every output while the writer keeps updating the order book,
+//a view loaded before is never changed by the later messages
+func TestViewUnchangedByWriter(t *testing.T) {
+	messages := readBusyStream(t, 2000)
+	b := newBusyBuilder(t, messages[:1000])
+
+	view := b.loadView()
+	want, err := json.Marshal(view.book)
+	if err != nil {
+		t.Fatal(err)
+	}
+	orders := view.book.FrontOrders(base.BidSide, 3)
+	positions := map[string]level3.QueuePosition{}
+	for _, order := range orders {
+		_, positions[order.OrderId], _ = view.book.QueuePosition(order.OrderId)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, msg := range messages[1000:] {
+			if err := b.updateFromStream(msg); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+
+	for reading := true; reading; {
+		select {
+		case <-done:
+			reading = false
+		default:
+		}
+
+		_, _ = b.GetPartOrderBook(0)
+		_, _ = b.GetL3PartOrderBook(100)
+		_, _ = b.GetAggregatedOrderBook(10, "1")
+		_, _ = b.GetL3ExtendedPartOrderBook(10)
+		_, _ = b.GetBookStats()
+		_, _ = b.GetMarketImpact(&exchanges.MarketOrder{Side: "buy", Size: "10"})
+		_, _ = b.GetOrderBookRange(&exchanges.BookRangeQuery{Bps: 100})
+		_, _ = b.GetL3OrderBookRange(&exchanges.BookRangeQuery{Size: "10"})
+		_, _ = b.GetL3BinarySnapshot()
+		for _, order := range orders {
+			_, _ = b.GetQueuePosition(order.OrderId)
+		}
+	}
+
+	got, err := json.Marshal(view.book)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) || view.sequence != 1001 {
+		t.Errorf("the view should not change, sequence %d", view.sequence)
+	}
+	for orderId, position := range positions {
+		if _, got, ok := view.book.QueuePosition(orderId); !ok || got != position {
+			t.Errorf("the queue position of %s should not change, got %+v, want %+v", orderId, got, position)
+		}
+	}
+
+	latest, err := b.SnapshotBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	all, err := newBusyBuilder(t, messages).SnapshotBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(latest) != string(all) {
+		t.Errorf("the latest view should have all messages")
+	}
+}
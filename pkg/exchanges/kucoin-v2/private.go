@@ -0,0 +1,93 @@
+package kucoin_v2
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/sdk"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/stream"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/services/log"
+	"go.uber.org/zap"
+)
+
+//privateConnection subscribes the order changes of our own account,
+//and feeds them to the OrderWatcher of their symbols
+type privateConnection struct {
+	ex    *Exchange
+	topic string
+}
+
+func newPrivateConnection(ex *Exchange) *privateConnection {
+	return &privateConnection{
+		ex:    ex,
+		topic: sdk.PrivateOrderTopic(defaultConfig.Type),
+	}
+}
+
+//run keeps the private websocket connection alive, it reconnects with backoff
+func (p *privateConnection) run() {
+	retry := newBackoff(defaultConfig.ReconnectMinDelay, defaultConfig.ReconnectMaxDelay)
+	for {
+		err := p.connect(retry)
+		log.Error("private websocket disconnected", zap.Error(err))
+
+		delay := retry.next()
+		log.Info("private websocket reconnect in " + delay.String())
+		time.Sleep(delay)
+	}
+}
+
+func (p *privateConnection) connect(retry *backoff) error {
+	tk, err := p.ex.apiService.WebSocketPrivateToken()
+	if err != nil {
+		return errors.New("WebSocketPrivateToken err: " + err.Error())
+	}
+
+	client := p.ex.apiService.NewWebSocketClient(tk)
+
+	mc, ec, err := client.Connect()
+	if err != nil {
+		return errors.New("Connect err: " + err.Error())
+	}
+
+	log.Info("subscribe: " + p.topic)
+	if err := client.Subscribe(sdk.NewSubscribeMessage(p.topic, true)); err != nil {
+		client.Stop()
+		return errors.New("Subscribe err: " + err.Error())
+	}
+	retry.reset()
+
+	for {
+		select {
+		case err := <-ec:
+			client.Stop() // Stop subscribing the WebSocket feed
+			return err
+
+		case msg, ok := <-mc:
+			if !ok {
+				client.Stop()
+				return errors.New("websocket message channel closed")
+			}
+			p.dispatch(msg)
+		}
+	}
+}
+
+//dispatch routes the order change by its symbol, the orders of unwatched symbols are dropped
+func (p *privateConnection) dispatch(msgRawData *sdk.WebSocketDownstreamMessage) {
+	if msgRawData.Topic != p.topic {
+		log.Warn("unknown private topic: " + msgRawData.Topic)
+		return
+	}
+
+	event, err := stream.NewPrivateEvent(msgRawData)
+	if err != nil {
+		log.Error("NewPrivateEvent err", zap.Error(err))
+		return
+	}
+
+	order := event.Data.(*stream.PrivateOrderModel)
+	if !p.ex.markets.dispatchPrivate(order.Symbol, event) {
+		log.Debug("private order of unknown symbol: " + order.Symbol)
+	}
+}
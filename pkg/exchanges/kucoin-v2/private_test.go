@@ -0,0 +1,64 @@
+package kucoin_v2
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/sdk"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/stream"
+)
+
+func TestPrivateDispatch(t *testing.T) {
+	defer func(consumers map[string]ConsumerConfig, spillDir string) {
+		defaultConfig.Consumers, defaultConfig.SpillDir = consumers, spillDir
+	}(defaultConfig.Consumers, defaultConfig.SpillDir)
+	defaultConfig.Consumers = map[string]ConsumerConfig{"private_orders": {ChanLen: 1}}
+	defaultConfig.SpillDir = t.TempDir()
+
+	ex := newTestExchange(newFakeClient())
+	m := newMarket(nil, "KCS-USDT")
+	if err := ex.markets.add(m); err != nil {
+		t.Fatal(err)
+	}
+	p := newPrivateConnection(ex)
+	dispatch := func(topic, symbol, orderId string) {
+		raw, _ := json.Marshal(map[string]interface{}{"symbol": symbol, "orderId": orderId, "type": stream.PrivateOrderOpenType, "ts": 1})
+		p.dispatch(&sdk.WebSocketDownstreamMessage{Topic: topic, Subject: "orderChange", RawData: raw})
+	}
+
+	//nobody reads the channel of one event, the others are spilled by the default policy
+	for _, orderId := range []string{"a", "b", "c"} {
+		dispatch(p.topic, "KCS-USDT", orderId)
+	}
+	dispatch(p.topic, "BTC-USDT", "unknown symbol")
+	dispatch("/unknown", "KCS-USDT", "unknown topic")
+
+	var stats ConsumerStats
+	for _, c := range m.private.stats() {
+		if c.Name == "private_orders" {
+			stats = c
+		}
+	}
+	if stats.Policy != string(policySpill) || stats.Spilled != 2 {
+		t.Errorf("the private order changes should follow the policy of their consumer, got %+v", stats)
+	}
+
+	for _, orderId := range []string{"a", "b", "c"} {
+		select {
+		case event := <-m.ow.PrivateMessages:
+			if order, ok := event.Data.(*stream.PrivateOrderModel); !ok || order.OrderId != orderId {
+				t.Errorf("want the decoded order change of %s, got %+v", orderId, event.Data)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("the order change of %s should be delivered", orderId)
+		}
+	}
+
+	//the order changes are dropped once the market is stopped
+	m.stop()
+	dispatch(p.topic, "KCS-USDT", "d")
+	if _, ok := <-m.ow.PrivateMessages; ok {
+		t.Error("the channel should be closed")
+	}
+}
@@ -1,7 +1,10 @@
 package sdk
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/Kucoin/kucoin-level3-sdk/pkg/services/log"
@@ -14,9 +17,17 @@ const (
 
 	urlFuturesSnapshot = "/api/v2/level3/snapshot"
 
+	urlSpotSymbols = "/api/v1/symbols"
+
+	urlFuturesContract = "/api/v1/contracts/"
+
 	topicSpotL3Prefix = "/spotMarket/level3:"
 
 	topicFutureL3Prefix = "/contractMarket/level3v2:"
+
+	topicSpotTradeOrders = "/spotMarket/tradeOrders"
+
+	topicFutureTradeOrders = "/contractMarket/tradeOrders"
 )
 
 type Kucoin struct {
@@ -49,6 +60,72 @@ func L3TopicPrefix(typ string) string {
 	return ret
 }
 
+//PrivateOrderTopic returns the private channel of the order changes of our own account
+func PrivateOrderTopic(typ string) string {
+	var ret string
+	switch typ {
+	case "spot":
+		ret = topicSpotTradeOrders
+	case "future":
+		ret = topicFutureTradeOrders
+	default:
+		log.Panic("market type error, must be spot or future")
+	}
+
+	return ret
+}
+
+type spotSymbolModel struct {
+	Symbol         string `json:"symbol"`
+	PriceIncrement string `json:"priceIncrement"`
+	BaseIncrement  string `json:"baseIncrement"`
+}
+
+type futuresContractModel struct {
+	Symbol     string      `json:"symbol"`
+	TickSize   json.Number `json:"tickSize"`
+	LotSize    json.Number `json:"lotSize"`
+	Multiplier json.Number `json:"multiplier"`
+}
+
+//SymbolIncrements returns the price increment and the size increment of the symbol,
+//the size of futures is a number of contracts (lots), each of multiplier units of the base currency, multiplier is empty of spot
+func (kucoin *Kucoin) SymbolIncrements(symbol string) (priceIncrement, sizeIncrement, multiplier string, err error) {
+	switch kucoin.typ {
+	case "spot":
+		resp, err := kucoin.httpClient.Request(http.MethodGet, urlSpotSymbols, nil)
+		if err != nil {
+			return "", "", "", err
+		}
+
+		var symbols []*spotSymbolModel
+		if err := resp.ReadJson(&symbols); err != nil {
+			return "", "", "", err
+		}
+		for _, s := range symbols {
+			if s.Symbol == symbol {
+				return s.PriceIncrement, s.BaseIncrement, "", nil
+			}
+		}
+		return "", "", "", fmt.Errorf("symbol not found: %s", symbol)
+
+	case "future":
+		resp, err := kucoin.httpClient.Request(http.MethodGet, urlFuturesContract+url.PathEscape(symbol), nil)
+		if err != nil {
+			return "", "", "", err
+		}
+
+		contract := &futuresContractModel{}
+		if err := resp.ReadJson(contract); err != nil {
+			return "", "", "", err
+		}
+		return contract.TickSize.String(), contract.LotSize.String(), contract.Multiplier.String(), nil
+	}
+
+	log.Panic("market type error, must be spot or future")
+	return "", "", "", nil
+}
+
 func (kucoin *Kucoin) AtomicFullOrderBook(symbol string) (*http_client.Response, error) {
 	var url string
 	switch kucoin.typ {
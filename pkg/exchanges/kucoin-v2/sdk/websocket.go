@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/url"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -161,12 +162,18 @@ type WebSocketClient struct {
 	// Error channel
 	errors chan error
 	// Downstream message channel
-	messages        chan *WebSocketDownstreamMessage
+	messages chan *WebSocketDownstreamMessage
+	// Serialize subscribe/unsubscribe requests waiting for their ack
+	subscribeLock *sync.Mutex
+	// Serialize writes to the connection
+	writeLock       *sync.Mutex
 	conn            *websocket.Conn
 	token           *WebSocketTokenModel
 	server          *WebSocketServerModel
 	enableHeartbeat bool
 	skipVerifyTls   bool
+	// Unix nano of the last frame received, messages, pongs and acks alike
+	lastReceiveTime int64
 }
 
 // NewWebSocketClient creates an instance of WebSocketClient.
@@ -177,6 +184,8 @@ func (kucoin *Kucoin) NewWebSocketClient(token *WebSocketTokenModel) *WebSocketC
 		errors:        make(chan error, 1),
 		pongs:         make(chan string, 1),
 		acks:          make(chan string, 1),
+		subscribeLock: &sync.Mutex{},
+		writeLock:     &sync.Mutex{},
 		token:         token,
 		messages:      make(chan *WebSocketDownstreamMessage, 2048),
 		skipVerifyTls: true,
@@ -213,15 +222,18 @@ func (wc *WebSocketClient) Connect() (<-chan *WebSocketDownstreamMessage, <-chan
 	for {
 		m := &WebSocketDownstreamMessage{}
 		if err := wc.conn.ReadJSON(m); err != nil {
+			_ = wc.conn.Close()
 			return wc.messages, wc.errors, err
 		}
 		if m.Type == ErrorMessage {
+			_ = wc.conn.Close()
 			return wc.messages, wc.errors, errors.Errorf("Error message: %s", ToJsonString(m))
 		}
 		if m.Type == WelcomeMessage {
 			break
 		}
 	}
+	atomic.StoreInt64(&wc.lastReceiveTime, time.Now().UnixNano())
 
 	wc.wg.Add(2)
 	go wc.read()
@@ -244,15 +256,16 @@ func (wc *WebSocketClient) read() {
 		default:
 			_, message, err := wc.conn.ReadMessage()
 			if err != nil {
-				wc.errors <- err
+				wc.sendError(err)
 				return
 			}
+			atomic.StoreInt64(&wc.lastReceiveTime, time.Now().UnixNano())
 			//fmt.Println(string(message))
 
 			m := &WebSocketDownstreamMessage{}
 			//log.Println("before ReadJSON")
 			if err := json.Unmarshal(message, m); err != nil {
-				wc.errors <- err
+				wc.sendError(err)
 				return
 			}
 			//log.Println(helper.ToJsonString(m))
@@ -262,23 +275,48 @@ func (wc *WebSocketClient) read() {
 			case WelcomeMessage:
 			case PongMessage:
 				if wc.enableHeartbeat {
-					wc.pongs <- m.Id
+					select {
+					case wc.pongs <- m.Id:
+					case <-wc.done:
+						return
+					}
 				}
 			case AckMessage:
 				// log.Printf("Subscribed: %s==%s? %s", channel.Id, m.Id, channel.Topic)
-				wc.acks <- m.Id
+				select {
+				case wc.acks <- m.Id:
+				case <-wc.done:
+					return
+				}
 			case ErrorMessage:
-				wc.errors <- errors.Errorf("Error message: %s", ToJsonString(m))
+				wc.sendError(errors.Errorf("Error message: %s", ToJsonString(m)))
 				return
 			case Message:
-				wc.messages <- m
+				select {
+				case wc.messages <- m:
+				case <-wc.done:
+					return
+				}
 			default:
-				wc.errors <- errors.Errorf("Unknown message type: %s", m.Type)
+				wc.sendError(errors.Errorf("Unknown message type: %s", m.Type))
 			}
 		}
 	}
 }
 
+// LastReceiveTime returns the unix nano time of the last frame received of any type, 0 before connected.
+func (wc *WebSocketClient) LastReceiveTime() int64 {
+	return atomic.LoadInt64(&wc.lastReceiveTime)
+}
+
+// sendError reports an error without blocking the goroutines after Stop.
+func (wc *WebSocketClient) sendError(err error) {
+	select {
+	case wc.errors <- err:
+	case <-wc.done:
+	}
+}
+
 func (wc *WebSocketClient) keepHeartbeat() {
 	wc.enableHeartbeat = true
 	// New ticker to send ping message
@@ -293,8 +331,8 @@ func (wc *WebSocketClient) keepHeartbeat() {
 		case <-pt.C:
 			p := NewPingMessage()
 			m := ToJsonString(p)
-			if err := wc.conn.WriteMessage(websocket.TextMessage, []byte(m)); err != nil {
-				wc.errors <- err
+			if err := wc.write(m); err != nil {
+				wc.sendError(err)
 				return
 			}
 
@@ -304,22 +342,35 @@ func (wc *WebSocketClient) keepHeartbeat() {
 			select {
 			case pid := <-wc.pongs:
 				if pid != p.Id {
-					wc.errors <- errors.Errorf("Invalid pong id %s, expect %s", pid, p.Id)
+					wc.sendError(errors.Errorf("Invalid pong id %s, expect %s", pid, p.Id))
 					return
 				}
+			case <-wc.done:
+				return
 			case <-time.After(time.Duration(wc.server.PingTimeout) * time.Millisecond):
-				wc.errors <- errors.Errorf("Wait pong message timeout in %d ms", wc.server.PingTimeout)
+				wc.sendError(errors.Errorf("Wait pong message timeout in %d ms", wc.server.PingTimeout))
 				return
 			}
 		}
 	}
 }
 
+// write writes a text message, the connection supports only one concurrent writer.
+func (wc *WebSocketClient) write(m string) error {
+	wc.writeLock.Lock()
+	defer wc.writeLock.Unlock()
+
+	return wc.conn.WriteMessage(websocket.TextMessage, []byte(m))
+}
+
 // Subscribe subscribes the specified channel.
 func (wc *WebSocketClient) Subscribe(channels ...*WebSocketSubscribeMessage) error {
+	wc.subscribeLock.Lock()
+	defer wc.subscribeLock.Unlock()
+
 	for _, c := range channels {
 		m := ToJsonString(c)
-		if err := wc.conn.WriteMessage(websocket.TextMessage, []byte(m)); err != nil {
+		if err := wc.write(m); err != nil {
 			return err
 		}
 		//log.Printf("Subscribing: %s, %s", c.Id, c.Topic)
@@ -338,9 +389,12 @@ func (wc *WebSocketClient) Subscribe(channels ...*WebSocketSubscribeMessage) err
 
 // Unsubscribe unsubscribes the specified channel.
 func (wc *WebSocketClient) Unsubscribe(channels ...*WebSocketUnsubscribeMessage) error {
+	wc.subscribeLock.Lock()
+	defer wc.subscribeLock.Unlock()
+
 	for _, c := range channels {
 		m := ToJsonString(c)
-		if err := wc.conn.WriteMessage(websocket.TextMessage, []byte(m)); err != nil {
+		if err := wc.write(m); err != nil {
 			return err
 		}
 		//log.Printf("Unsubscribing: %s, %s", c.Id, c.Topic)
@@ -0,0 +1,163 @@
+package kucoin_v2
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/sdk"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/stream"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/services/log"
+	"go.uber.org/zap"
+)
+
+//spillQueue queues the events of a consumer on disk while its channel is full, and feeds them back in order,
+//the raw messages are written one per line and decoded again when they are read back
+type spillQueue struct {
+	path   string
+	decode decoder
+	events chan *stream.Event
+
+	lock    *sync.Mutex
+	writer  *os.File
+	pending int //events written and not fed back yet
+
+	wake    chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+func newSpillQueue(symbol, name, dir string, events chan *stream.Event, decode decoder) *spillQueue {
+	q := &spillQueue{
+		path:   filepath.Join(dir, symbol+"."+name+".spill"),
+		decode: decode,
+		events: events,
+
+		lock: &sync.Mutex{},
+
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go q.run()
+
+	return q
+}
+
+func (q *spillQueue) len() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return q.pending
+}
+
+func (q *spillQueue) push(event *stream.Event) {
+	data, err := json.Marshal(event.Raw)
+	if err != nil {
+		log.Panic("spill panic", zap.Error(err))
+	}
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.writer == nil {
+		if err := os.MkdirAll(filepath.Dir(q.path), 0755); err != nil {
+			log.Panic("spill panic", zap.Error(err))
+		}
+		q.writer, err = os.OpenFile(q.path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+		if err != nil {
+			log.Panic("spill panic", zap.Error(err))
+		}
+	}
+	if _, err := q.writer.Write(append(data, '\n')); err != nil {
+		log.Panic("spill panic", zap.Error(err))
+	}
+	q.pending++
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+//run feeds the spilled events back to the channel, the file is removed whenever the queue is empty
+func (q *spillQueue) run() {
+	defer close(q.stopped)
+
+	var file *os.File
+	var reader *bufio.Reader
+	for {
+		if q.len() == 0 {
+			select {
+			case <-q.wake:
+				continue
+			case <-q.done:
+				return
+			}
+		}
+
+		if reader == nil {
+			var err error
+			if file, err = os.Open(q.path); err != nil {
+				log.Panic("spill panic", zap.Error(err))
+			}
+			reader = bufio.NewReader(file)
+		}
+
+		//the line is complete, it is written before pending counts it
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			log.Panic("spill panic", zap.Error(err))
+		}
+		msg := &sdk.WebSocketDownstreamMessage{}
+		if err := json.Unmarshal(line, msg); err != nil {
+			log.Panic("spill panic", zap.Error(err))
+		}
+		event, err := q.decode(msg)
+		if err != nil {
+			log.Panic("spill panic", zap.Error(err))
+		}
+
+		select {
+		case q.events <- event:
+		case <-q.done:
+			file.Close()
+			return
+		}
+
+		q.lock.Lock()
+		q.pending--
+		if q.pending == 0 {
+			file.Close()
+			reader = nil
+			q.removeLocked()
+		}
+		q.lock.Unlock()
+	}
+}
+
+func (q *spillQueue) removeLocked() {
+	if q.writer == nil {
+		return
+	}
+
+	q.writer.Close()
+	q.writer = nil
+	if err := os.Remove(q.path); err != nil {
+		log.Error("remove spill file error", zap.Error(err))
+	}
+}
+
+//close stops feeding the channel and removes the spilled events, the caller closes the channel afterwards
+func (q *spillQueue) close() {
+	close(q.done)
+	<-q.stopped
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.pending = 0
+	q.removeLocked()
+}
@@ -1,41 +1,11 @@
 package stream
 
-import (
-	"encoding/json"
-
-	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/sdk"
-)
-
 type SequenceModel struct {
 	Sequence uint64 `json:"sequence"`
 }
 
-//Level 3 websocket stream
-type DataModel struct {
-	Type string `json:"type"`
-
-	Sequence uint64 `json:"sequence"`
-
-	//Symbol     string `json:"symbol"`
-
-	rawData json.RawMessage
-}
-
-func NewStreamDataModel(msgData *sdk.WebSocketDownstreamMessage) (*DataModel, error) {
-	data := &SequenceModel{}
-	if err := json.Unmarshal(msgData.RawData, data); err != nil {
-		return nil, err
-	}
-
-	return &DataModel{
-		Type:     msgData.Subject,
-		Sequence: data.Sequence,
-		rawData:  msgData.RawData,
-	}, nil
-}
-
-func (l3Data *DataModel) Data() json.RawMessage {
-	return l3Data.rawData
+func (m SequenceModel) sequence() uint64 {
+	return m.Sequence
 }
 
 const (
@@ -50,12 +20,14 @@ const (
 )
 
 type DataReceivedModel struct {
+	SequenceModel
 	OrderId   string `json:"orderId"`
 	ClientOid string `json:"clientOid"`
 	Time      uint64 `json:"ts"`
 }
 
 type DataOpenModel struct {
+	SequenceModel
 	Side      string `json:"side"`
 	Size      string `json:"size"`
 	Price     string `json:"price"`
@@ -65,12 +37,14 @@ type DataOpenModel struct {
 }
 
 type DataUpdateModel struct {
+	SequenceModel
 	OrderId string `json:"orderId"`
 	Size    string `json:"size"`
 	Time    uint64 `json:"ts"`
 }
 
 type DataMatchModel struct {
+	SequenceModel
 	Side         string `json:"side"`
 	Price        string `json:"price"`
 	Size         string `json:"size"`
@@ -82,6 +56,7 @@ type DataMatchModel struct {
 }
 
 type DataDoneModel struct {
+	SequenceModel
 	OrderId string `json:"orderId"`
 	Reason  string `json:"reason"`
 	Time    uint64 `json:"ts"`
@@ -0,0 +1,101 @@
+package stream
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/sdk"
+)
+
+//Event is a level3 message decoded once and shared by all the consumers, which must not modify it.
+//Data is the model of the Type: *DataOpenModel etc of the spot stream, *FutureDataOpenModel etc of the futures stream
+type Event struct {
+	Type     string
+	Sequence uint64
+	Data     interface{}
+
+	Raw *sdk.WebSocketDownstreamMessage //the message as delivered, for the publishers and the recorders
+}
+
+type eventData interface {
+	sequence() uint64
+}
+
+func NewEvent(msg *sdk.WebSocketDownstreamMessage, future bool) (*Event, error) {
+	data := newEventData(msg.Subject, future)
+	if data == nil {
+		return nil, errors.New("error msg type: " + msg.Subject)
+	}
+	if err := json.Unmarshal(msg.RawData, data); err != nil {
+		return nil, err
+	}
+
+	return &Event{
+		Type:     msg.Subject,
+		Sequence: data.sequence(),
+		Data:     data,
+		Raw:      msg,
+	}, nil
+}
+
+var sequenceKey = []byte(`"sequence"`)
+
+//PeekSequence reads the sequence of the raw data without decoding it, to drop duplicates before NewEvent,
+//ok is false if the raw data has no numeric sequence
+func PeekSequence(raw []byte) (sequence uint64, ok bool) {
+	i := bytes.Index(raw, sequenceKey)
+	if i < 0 {
+		return 0, false
+	}
+
+	rest := bytes.TrimLeft(raw[i+len(sequenceKey):], " \t\r\n")
+	if len(rest) == 0 || rest[0] != ':' {
+		return 0, false
+	}
+	rest = bytes.TrimLeft(rest[1:], " \t\r\n")
+
+	n := 0
+	for n < len(rest) && rest[n] >= '0' && rest[n] <= '9' {
+		n++
+	}
+	if n == 0 {
+		return 0, false
+	}
+
+	sequence, err := strconv.ParseUint(string(rest[:n]), 10, 64)
+	return sequence, err == nil
+}
+
+func newEventData(subject string, future bool) eventData {
+	if future {
+		switch subject {
+		case FutureMessageReceivedType:
+			return &FutureDataReceivedModel{}
+		case FutureMessageOpenType:
+			return &FutureDataOpenModel{}
+		case FutureMessageUpdateType:
+			return &FutureDataUpdateModel{}
+		case FutureMessageMatchType:
+			return &FutureDataMatchModel{}
+		case FutureMessageDoneType:
+			return &FutureDataDoneModel{}
+		}
+		return nil
+	}
+
+	switch subject {
+	case MessageReceivedType:
+		return &DataReceivedModel{}
+	case MessageOpenType:
+		return &DataOpenModel{}
+	case MessageUpdateType:
+		return &DataUpdateModel{}
+	case MessageMatchType:
+		return &DataMatchModel{}
+	case MessageDoneType:
+		return &DataDoneModel{}
+	}
+	return nil
+}
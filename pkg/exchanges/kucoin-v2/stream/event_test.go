@@ -0,0 +1,50 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/sdk"
+)
+
+func TestNewEvent(t *testing.T) {
+	raw := []byte(`{"sequence":12,"side":"sell","price":"1","size":"30","remainSize":"70","takerOrderId":"t","makerOrderId":"m","ts":5}`)
+
+	event, err := NewEvent(&sdk.WebSocketDownstreamMessage{Subject: MessageMatchType, RawData: raw}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	match, ok := event.Data.(*DataMatchModel)
+	if !ok || event.Sequence != 12 || match.Sequence != 12 || match.RemainSize != "70" || match.MakerOrderId != "m" || match.Time != 5 {
+		t.Errorf("unexpected spot event: %+v, %+v", event, event.Data)
+	}
+
+	event, err = NewEvent(&sdk.WebSocketDownstreamMessage{Subject: FutureMessageMatchType, RawData: raw}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if future, ok := event.Data.(*FutureDataMatchModel); !ok || event.Sequence != 12 || future.Size != "30" {
+		t.Errorf("unexpected futures event: %+v, %+v", event, event.Data)
+	}
+
+	if _, err := NewEvent(&sdk.WebSocketDownstreamMessage{Subject: "unknown", RawData: raw}, false); err == nil {
+		t.Errorf("an unknown type should fail")
+	}
+}
+
+func TestPeekSequence(t *testing.T) {
+	for raw, want := range map[string]uint64{
+		`{"sequence":12,"orderId":"a"}`:               12,
+		`{"orderId":"a", "sequence" : 1690000000123}`: 1690000000123,
+		`{"sequence":0}`:                              0,
+	} {
+		if sequence, ok := PeekSequence([]byte(raw)); !ok || sequence != want {
+			t.Errorf("%s: want %d, got %d, %v", raw, want, sequence, ok)
+		}
+	}
+
+	for _, raw := range []string{`{"orderId":"a"}`, `{"sequence":"12"}`, `{"sequence":}`, `{"sequence"`} {
+		if sequence, ok := PeekSequence([]byte(raw)); ok {
+			t.Errorf("%s: no sequence expected, got %d", raw, sequence)
+		}
+	}
+}
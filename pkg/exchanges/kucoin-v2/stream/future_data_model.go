@@ -0,0 +1,60 @@
+package stream
+
+//Futures level3v2 websocket stream, /contractMarket/level3v2:{symbol}
+//sizes are numbers of contracts, the match message carries the matched size instead of the remaining size
+const (
+	FutureMessageReceivedType = "received"
+	FutureMessageOpenType     = "open"
+	FutureMessageUpdateType   = "update"
+	FutureMessageMatchType    = "match"
+	FutureMessageDoneType     = "done"
+)
+
+type FutureDataReceivedModel struct {
+	SequenceModel
+	Symbol    string `json:"symbol"`
+	OrderId   string `json:"orderId"`
+	ClientOid string `json:"clientOid"`
+	Time      uint64 `json:"ts"`
+}
+
+type FutureDataOpenModel struct {
+	SequenceModel
+	Symbol    string `json:"symbol"`
+	Side      string `json:"side"`
+	Price     string `json:"price"`
+	Size      string `json:"size"`
+	OrderId   string `json:"orderId"`
+	OrderTime uint64 `json:"orderTime"`
+	Time      uint64 `json:"ts"`
+}
+
+//FutureDataUpdateModel changes the size of an order, Size is the new size
+type FutureDataUpdateModel struct {
+	SequenceModel
+	Symbol  string `json:"symbol"`
+	OrderId string `json:"orderId"`
+	Size    string `json:"size"`
+	Time    uint64 `json:"ts"`
+}
+
+//FutureDataMatchModel matches the maker order, Size is the matched size
+type FutureDataMatchModel struct {
+	SequenceModel
+	Symbol       string `json:"symbol"`
+	Side         string `json:"side"`
+	Price        string `json:"price"`
+	Size         string `json:"size"`
+	TakerOrderId string `json:"takerOrderId"`
+	MakerOrderId string `json:"makerOrderId"`
+	TradeId      string `json:"tradeId"`
+	Time         uint64 `json:"ts"`
+}
+
+type FutureDataDoneModel struct {
+	SequenceModel
+	Symbol  string `json:"symbol"`
+	OrderId string `json:"orderId"`
+	Reason  string `json:"reason"`
+	Time    uint64 `json:"ts"`
+}
@@ -0,0 +1,83 @@
+package stream
+
+import (
+	"encoding/json"
+
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/sdk"
+)
+
+//private order change types of the tradeOrders channel
+const (
+	PrivateOrderReceivedType = "received"
+	PrivateOrderOpenType     = "open"
+	PrivateOrderMatchType    = "match"
+	PrivateOrderFilledType   = "filled"
+	PrivateOrderCanceledType = "canceled"
+	PrivateOrderUpdateType   = "update"
+
+	PrivateOrderStatusNew   = "new"
+	PrivateOrderStatusOpen  = "open"
+	PrivateOrderStatusMatch = "match"
+	PrivateOrderStatusDone  = "done"
+)
+
+//PrivateOrderModel is an order change of our own account
+type PrivateOrderModel struct {
+	Symbol    string `json:"symbol"`
+	OrderType string `json:"orderType"`
+	Side      string `json:"side"`
+	OrderId   string `json:"orderId"`
+	ClientOid string `json:"clientOid"`
+	Type      string `json:"type"`
+	Status    string `json:"status"`
+	Liquidity string `json:"liquidity"`
+
+	Price        string `json:"price"`
+	Size         string `json:"size"`
+	FilledSize   string `json:"filledSize"`
+	RemainSize   string `json:"remainSize"`
+	CanceledSize string `json:"canceledSize"`
+	OldSize      string `json:"oldSize"`
+
+	TradeId    string `json:"tradeId"`
+	MatchPrice string `json:"matchPrice"`
+	MatchSize  string `json:"matchSize"`
+
+	Fee         string `json:"fee"`
+	FeeType     string `json:"feeType"`
+	FeeCurrency string `json:"feeCurrency"`
+
+	OrderTime uint64 `json:"orderTime"`
+	Time      uint64 `json:"ts"`
+}
+
+func NewPrivateOrderModel(msgData *sdk.WebSocketDownstreamMessage) (*PrivateOrderModel, error) {
+	data := &PrivateOrderModel{}
+	if err := json.Unmarshal(msgData.RawData, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+//NewPrivateEvent decodes the order change once for the consumers, Data is the *PrivateOrderModel,
+//Sequence is 0 as the private order changes have none
+func NewPrivateEvent(msg *sdk.WebSocketDownstreamMessage) (*Event, error) {
+	order, err := NewPrivateOrderModel(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Event{
+		Type: order.Type,
+		Data: order,
+		Raw:  msg,
+	}, nil
+}
+
+//Done reports whether the order is removed from the order book
+func (order *PrivateOrderModel) Done() bool {
+	return order.Status == PrivateOrderStatusDone ||
+		order.Type == PrivateOrderFilledType ||
+		order.Type == PrivateOrderCanceledType
+}
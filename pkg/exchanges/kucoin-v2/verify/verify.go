@@ -10,6 +10,7 @@ import (
 
 	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/orderbook"
 	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/sdk"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/exchanges/kucoin-v2/stream"
 	"github.com/Kucoin/kucoin-level3-sdk/pkg/services/log"
 	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
@@ -17,8 +18,8 @@ import (
 
 type Verify struct {
 	level3Builder   *orderbook.Builder
-	Messages        chan *sdk.WebSocketDownstreamMessage //方便记录两次快照之间的数据流，方便在快照校验失败后，重复快照
-	verifyFrequency time.Duration                        //校验频率
+	Messages        chan *stream.Event //方便记录两次快照之间的数据流，方便在快照校验失败后，重复快照
+	verifyFrequency time.Duration      //校验频率
 
 	verifyLogDirectory string
 	update             *os.File
@@ -34,7 +35,7 @@ func NewVerify(level3Builder *orderbook.Builder, verifyFrequency uint64, verifyL
 	verifyLogDirectory = strings.TrimRight(verifyLogDirectory, "/")
 	v := &Verify{
 		level3Builder:      level3Builder,
-		Messages:           make(chan *sdk.WebSocketDownstreamMessage, 1024),
+		Messages:           make(chan *stream.Event, 1024),
 		verifyFrequency:    time.Duration(verifyFrequency) * time.Second,
 		verifyLogDirectory: strings.TrimRight(verifyLogDirectory, "/") + "/",
 		uniqStr:            uniqStr,
@@ -76,7 +77,7 @@ func (v *Verify) Run() {
 	const atomicStartLen = 10
 
 	for msg := range v.Messages {
-		v.writeWsMsg(msg)
+		v.writeWsMsg(msg.Raw)
 		if time.Now().After(v.nextVerifyTime) {
 			//获取快照
 			snapshot, err := v.level3Builder.Snapshot()
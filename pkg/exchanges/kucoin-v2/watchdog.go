@@ -0,0 +1,75 @@
+package kucoin_v2
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/services/log"
+	"go.uber.org/zap"
+)
+
+//watchdog marks the order books stale when their feed is silent,
+//and forces a connection to reconnect when it receives nothing, not even pongs
+func (ex *Exchange) watchdog() {
+	if defaultConfig.StaleTimeout <= 0 && defaultConfig.ReconnectTimeout <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		ex.checkSilence(now)
+	}
+}
+
+func (ex *Exchange) checkSilence(now time.Time) {
+	if defaultConfig.StaleTimeout > 0 {
+		for _, m := range ex.markets.all() {
+			silence := now.Sub(time.Unix(0, atomic.LoadInt64(&m.lastMessageTime)))
+			if silence > defaultConfig.StaleTimeout && m.ob.MarkStale() {
+				log.Warn("order book is stale, no message received", zap.String("symbol", m.symbol), zap.Duration("silence", silence))
+			}
+		}
+	}
+
+	if defaultConfig.ReconnectTimeout <= 0 {
+		return
+	}
+
+	//a quiet market is not a dead connection, only a connection receiving nothing at all is reconnected,
+	//one at a time while the others are alive, so that the order books keep their feed,
+	//a disconnected connection may wait in the reconnect backoff, the silent one is reconnected if no other is receiving
+	var silent *connection
+	var longest time.Duration
+	disconnected, receiving := false, 0
+	for _, c := range ex.conns {
+		connectedTime := atomic.LoadInt64(&c.connectedTime)
+		if connectedTime == 0 {
+			disconnected = true
+			continue
+		}
+
+		//count the silence from the connected time, so that a new connection has time to receive
+		since := c.lastReceiveTime()
+		if connectedTime > since {
+			since = connectedTime
+		}
+
+		silence := now.Sub(time.Unix(0, since))
+		if silence <= defaultConfig.ReconnectTimeout {
+			receiving++
+			continue
+		}
+		if silence > longest {
+			silent, longest = c, silence
+		}
+	}
+
+	if silent == nil || disconnected && receiving > 0 {
+		return
+	}
+
+	log.Warn("connection is silent, reconnect websocket", zap.Int("connection", silent.id), zap.Duration("silence", longest))
+	silent.forceReconnect()
+}
@@ -0,0 +1,69 @@
+package kucoin_v2
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCheckSilence(t *testing.T) {
+	defer func(stale, reconnect time.Duration) {
+		defaultConfig.StaleTimeout, defaultConfig.ReconnectTimeout = stale, reconnect
+	}(defaultConfig.StaleTimeout, defaultConfig.ReconnectTimeout)
+	defaultConfig.StaleTimeout, defaultConfig.ReconnectTimeout = time.Second, time.Minute
+
+	clients := []*fakeClient{newFakeClient(), newFakeClient(), newFakeClient(), newFakeClient()}
+	ex := newTestExchange(clients...)
+	now := time.Now()
+	forced := func(want int) {
+		t.Helper()
+		for i, c := range ex.conns {
+			select {
+			case <-c.reconnect:
+				if i != want {
+					t.Errorf("connection %d should not be forced to reconnect", i)
+				}
+			default:
+				if i == want {
+					t.Errorf("connection %d should be forced to reconnect", i)
+				}
+			}
+		}
+	}
+
+	//the market is quiet for an hour, its feed silence alone reconnects nothing
+	m := newMarket(nil, "KCS-USDT")
+	m.lastMessageTime = now.Add(-time.Hour).UnixNano()
+	if err := ex.markets.add(m); err != nil {
+		t.Fatal(err)
+	}
+
+	//0 received a pong recently, 1 and 2 received nothing for 2m and 3m, 3 connected recently
+	for _, c := range ex.conns {
+		c.connectedTime = now.Add(-time.Hour).UnixNano()
+	}
+	clients[0].lastReceive = now.Add(-time.Second).UnixNano()
+	clients[1].lastReceive = now.Add(-2 * time.Minute).UnixNano()
+	clients[2].lastReceive = now.Add(-3 * time.Minute).UnixNano()
+	ex.conns[3].connectedTime = now.Add(-time.Second).UnixNano()
+
+	//the most silent one is reconnected first, one at a time
+	ex.checkSilence(now)
+	forced(2)
+
+	//no other one is reconnected while one is disconnected and another is receiving
+	ex.conns[2].resetClient()
+	ex.checkSilence(now)
+	forced(-1)
+
+	//the disconnected one may wait in the backoff, the most silent one is reconnected when no other is receiving
+	clients[0].lastReceive = now.Add(-5 * time.Minute).UnixNano()
+	clients[3].lastReceive = now.Add(-4 * time.Minute).UnixNano()
+	ex.conns[3].connectedTime = now.Add(-time.Hour).UnixNano()
+	ex.checkSilence(now)
+	forced(0)
+
+	//a single silent connection is reconnected
+	ex.conns = ex.conns[1:2]
+	ex.checkSilence(now)
+	forced(0)
+}
@@ -0,0 +1,156 @@
+//package fixed represents prices and sizes as int64 scaled by a power of ten,
+//formatted the same as decimal.Decimal.String()
+package fixed
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+//MaxDecimals keeps the scale factor within int64
+const MaxDecimals = 18
+
+var ErrOverflow = errors.New("fixed: int64 overflow")
+
+//ErrDecimals is returned by Parse for a value finer than the scale
+var ErrDecimals = errors.New("fixed: more decimals than the scale")
+
+//Scale converts between strings and int64 with Decimals digits after the decimal point
+type Scale struct {
+	Decimals int32
+	factor   int64
+}
+
+func NewScale(decimals int32) (Scale, error) {
+	if decimals < 0 || decimals > MaxDecimals {
+		return Scale{}, fmt.Errorf("fixed: decimals must be between 0 and %d, not %d", MaxDecimals, decimals)
+	}
+
+	factor := int64(1)
+	for i := int32(0); i < decimals; i++ {
+		factor *= 10
+	}
+	return Scale{Decimals: decimals, factor: factor}, nil
+}
+
+//ScaleOf returns the scale of an increment of the symbol metadata, e.g. "0.001" => 3, "1" => 0
+func ScaleOf(increment string) (Scale, error) {
+	increment = strings.TrimRight(increment, "0")
+	i := strings.IndexByte(increment, '.')
+	if i < 0 {
+		return NewScale(0)
+	}
+
+	return NewScale(int32(len(increment) - i - 1))
+}
+
+//Parse parses a decimal string, digits beyond the scale must be zeros
+func (s Scale) Parse(v string) (int64, error) {
+	if v == "" {
+		return 0, fmt.Errorf("fixed: parse empty string")
+	}
+
+	negative := false
+	str := v
+	switch str[0] {
+	case '-':
+		negative = true
+		str = str[1:]
+	case '+':
+		str = str[1:]
+	}
+
+	integer, fraction := str, ""
+	if i := strings.IndexByte(str, '.'); i >= 0 {
+		integer, fraction = str[:i], str[i+1:]
+	}
+	if integer == "" && fraction == "" {
+		return 0, fmt.Errorf("fixed: parse `%s`: no digits", v)
+	}
+
+	var ret int64
+	digit := func(c byte) error {
+		if c < '0' || c > '9' {
+			return fmt.Errorf("fixed: parse `%s`: invalid character %q", v, c)
+		}
+		if ret > (math.MaxInt64-int64(c-'0'))/10 {
+			return fmt.Errorf("fixed: parse `%s`: %w", v, ErrOverflow)
+		}
+		ret = ret*10 + int64(c-'0')
+		return nil
+	}
+
+	for i := 0; i < len(integer); i++ {
+		if err := digit(integer[i]); err != nil {
+			return 0, err
+		}
+	}
+	for i := 0; i < len(fraction); i++ {
+		if int32(i) >= s.Decimals {
+			if fraction[i] != '0' {
+				return 0, fmt.Errorf("fixed: parse `%s`: %w %d", v, ErrDecimals, s.Decimals)
+			}
+			continue
+		}
+		if err := digit(fraction[i]); err != nil {
+			return 0, err
+		}
+	}
+	for i := int32(len(fraction)); i < s.Decimals; i++ {
+		if err := digit('0'); err != nil {
+			return 0, err
+		}
+	}
+
+	if negative {
+		ret = -ret
+	}
+	return ret, nil
+}
+
+//Format formats the value without trailing zeros, the same as decimal.Decimal.String()
+func (s Scale) Format(v int64) string {
+	if s.Decimals == 0 {
+		return strconv.FormatInt(v, 10)
+	}
+
+	sign := ""
+	u := uint64(v)
+	if v < 0 {
+		sign = "-"
+		u = uint64(-v)
+	}
+
+	integer := u / uint64(s.factor)
+	fraction := u % uint64(s.factor)
+	if fraction == 0 {
+		return sign + strconv.FormatUint(integer, 10)
+	}
+
+	digits := strconv.FormatUint(fraction, 10)
+	digits = strings.Repeat("0", int(s.Decimals)-len(digits)) + digits
+	return sign + strconv.FormatUint(integer, 10) + "." + strings.TrimRight(digits, "0")
+}
+
+//Add returns a + b, or ErrOverflow
+func Add(a, b int64) (int64, error) {
+	c := a + b
+	if (c > a) != (b > 0) {
+		return 0, ErrOverflow
+	}
+
+	return c, nil
+}
+
+//Sub returns a - b, or ErrOverflow
+func Sub(a, b int64) (int64, error) {
+	c := a - b
+	if (c < a) != (b > 0) {
+		return 0, ErrOverflow
+	}
+
+	return c, nil
+}
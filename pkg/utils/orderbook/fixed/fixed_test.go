@@ -0,0 +1,74 @@
+package fixed
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestScaleOf(t *testing.T) {
+	for increment, want := range map[string]int32{
+		"1":          0,
+		"10":         0,
+		"0.1":        1,
+		"0.00000001": 8,
+		"0.0010":     3,
+		"1.0":        0,
+	} {
+		s, err := ScaleOf(increment)
+		if err != nil || s.Decimals != want {
+			t.Errorf("ScaleOf(%s) should be %d, not %d, %v", increment, want, s.Decimals, err)
+		}
+	}
+}
+
+//TestFormatMatchesDecimal checks the output is byte-identical to decimal.Decimal.String()
+func TestFormatMatchesDecimal(t *testing.T) {
+	s, _ := NewScale(8)
+	for _, v := range []string{
+		"0", "0.0", "1", "10", "100.00", "0.1", "0.10000000", "1.5", "-1.5", "-0.00000001",
+		"9000.12345678", "123456789.00000001", "0.000000010", "+2.50",
+	} {
+		got, err := s.Parse(v)
+		if err != nil {
+			t.Errorf("Parse(%s) error: %v", v, err)
+			continue
+		}
+		if want := decimal.RequireFromString(v).String(); s.Format(got) != want {
+			t.Errorf("Format(Parse(%s)) should be %s, not %s", v, want, s.Format(got))
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	s, _ := NewScale(2)
+	for _, v := range []string{"", ".", "-", "1.001", "1a", "1.2.3"} {
+		if _, err := s.Parse(v); err == nil {
+			t.Errorf("Parse(%q) should fail", v)
+		}
+	}
+
+	if _, err := s.Parse("92233720368547758.08"); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Parse should overflow, not %v", err)
+	}
+	if v, err := s.Parse("92233720368547758.07"); err != nil || v != math.MaxInt64 {
+		t.Errorf("Parse should return MaxInt64, not %d, %v", v, err)
+	}
+}
+
+func TestOverflow(t *testing.T) {
+	if _, err := Add(math.MaxInt64, 1); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Add should overflow")
+	}
+	if _, err := Sub(math.MinInt64, 1); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Sub should overflow")
+	}
+	if v, err := Add(-5, 3); err != nil || v != -2 {
+		t.Errorf("Add(-5, 3) should be -2, not %d, %v", v, err)
+	}
+	if v, err := Sub(3, 5); err != nil || v != -2 {
+		t.Errorf("Sub(3, 5) should be -2, not %d, %v", v, err)
+	}
+}
@@ -9,8 +9,8 @@ import (
 )
 
 type OrderBook struct {
-	Asks *skiplist.SkipList //Sort price from low to high
-	Bids *skiplist.SkipList //Sort price from high to low
+	Asks *orderList //Sort price from low to high
+	Bids *orderList //Sort price from high to low
 }
 
 func NewOrderBook() *OrderBook {
@@ -20,34 +20,26 @@ func NewOrderBook() *OrderBook {
 	}
 }
 
-func isEqual(l, r interface{}) bool {
-	switch val := l.(type) {
-	case decimal.Decimal:
-		cVal := r.(decimal.Decimal)
-		if !val.Equals(cVal) {
-			return false
-		}
-	default:
-		if val != r {
-			return false
-		}
-	}
-	return true
+//orderList sorts the price levels of a side by price
+type orderList = skiplist.SkipList[decimal.Decimal, *Order]
+
+func isEqual(l, r decimal.Decimal) bool {
+	return l.Equal(r)
 }
 
-func newAskOrders() *skiplist.SkipList {
-	return skiplist.NewCustomMap(func(l, r interface{}) bool {
-		return l.(decimal.Decimal).LessThan(r.(decimal.Decimal))
+func newAskOrders() *orderList {
+	return skiplist.NewCustomMap[decimal.Decimal, *Order](func(l, r decimal.Decimal) bool {
+		return l.LessThan(r)
 	}, isEqual)
 }
 
-func newBidOrders() *skiplist.SkipList {
-	return skiplist.NewCustomMap(func(l, r interface{}) bool {
-		return l.(decimal.Decimal).GreaterThan(r.(decimal.Decimal))
+func newBidOrders() *orderList {
+	return skiplist.NewCustomMap[decimal.Decimal, *Order](func(l, r decimal.Decimal) bool {
+		return l.GreaterThan(r)
 	}, isEqual)
 }
 
-func (ob *OrderBook) getOrderBookBySide(side string) (*skiplist.SkipList, error) {
+func (ob *OrderBook) getOrderBookBySide(side string) (*orderList, error) {
 	if err := base.CheckSide(side); err != nil {
 		return nil, err
 	}
@@ -87,7 +79,7 @@ func (ob *OrderBook) GetPartOrderBookBySide(side string, number int) [][2]string
 		return nil
 	}
 
-	var it skiplist.Iterator
+	var it skiplist.Iterator[decimal.Decimal, *Order]
 	if side == base.AskSide {
 		it = ob.Asks.Iterator()
 		if number == 0 {
@@ -108,7 +100,7 @@ func (ob *OrderBook) GetPartOrderBookBySide(side string, number int) [][2]string
 	it.Next()
 
 	for i := 0; i < number; i++ {
-		order := it.Value().(*Order)
+		order := it.Value()
 		arr[i] = [2]string{order.Price.String(), order.Size.String()}
 		if !it.Next() {
 			break
@@ -121,17 +113,9 @@ func (ob *OrderBook) GetPartOrderBookBySide(side string, number int) [][2]string
 func (ob *OrderBook) GetOrderBookTickerOrder() (askOrder, bidOrder *Order) {
 	askIT := ob.Asks.Iterator()
 	askIT.Next()
-	ask := askIT.Value()
-	switch ask.(type) {
-	case *Order:
-		askOrder = ask.(*Order)
-	}
+	askOrder = askIT.Value()
 	bidIT := ob.Bids.Iterator()
 	bidIT.Next()
-	bid := bidIT.Value()
-	switch bid.(type) {
-	case *Order:
-		bidOrder = bid.(*Order)
-	}
+	bidOrder = bidIT.Value()
 	return
 }
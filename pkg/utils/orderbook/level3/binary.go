@@ -0,0 +1,221 @@
+package level3
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/base"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/fixed"
+)
+
+//The binary form of the full order book, the integers are varints unless noted:
+//
+//	magic "KL3B", version uint16 big endian
+//	header length, header: price decimals, size decimals, sequence, book time
+//	the asks from the best price, then the bids from the best price, for each side:
+//	    count of orders, then in the order of the queues:
+//	    record length, record: orderId length, orderId, price (signed), size (signed), time, sequence
+//
+//the decoder skips the bytes appended to the header and the records,
+//so fields are added without a new version, which is only for the changes older decoders would misread,
+//version 2 adds the sequence which opened the order, the queue priority among orders of the same time
+const (
+	BinaryVersion = 2
+
+	binaryMagic = "KL3B"
+
+	maxBinaryRecordLen = 1 << 16
+)
+
+var ErrBinaryFormat = errors.New("level3: invalid binary order book")
+
+func appendUvarint(buf []byte, v uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	return append(buf, tmp[:n]...)
+}
+
+func appendVarint(buf []byte, v int64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(tmp[:], v)
+	return append(buf, tmp[:n]...)
+}
+
+//EncodeBinary writes the view of the order book with the bookTime in the binary form
+func (v *View) EncodeBinary(w io.Writer, bookTime uint64) error {
+	bw := bufio.NewWriter(w)
+
+	var prefix [len(binaryMagic) + 2]byte
+	copy(prefix[:], binaryMagic)
+	binary.BigEndian.PutUint16(prefix[len(binaryMagic):], BinaryVersion)
+	bw.Write(prefix[:])
+
+	header := appendUvarint(nil, uint64(v.PriceScale.Decimals))
+	header = appendUvarint(header, uint64(v.SizeScale.Decimals))
+	header = appendUvarint(header, v.Sequence)
+	header = appendUvarint(header, bookTime)
+	writeBinaryRecord(bw, header)
+
+	var record []byte
+	for _, side := range []string{base.AskSide, base.BidSide} {
+		count := 0
+		v.RangeLevels(side, func(level *LevelView) bool {
+			count += level.Count
+			return true
+		})
+		bw.Write(appendUvarint(nil, uint64(count)))
+
+		v.RangeLevels(side, func(level *LevelView) bool {
+			level.RangeOrders(func(order *Order) bool {
+				record = appendUvarint(record[:0], uint64(len(order.OrderId)))
+				record = append(record, order.OrderId...)
+				record = appendVarint(record, order.Price)
+				record = appendVarint(record, order.Size)
+				record = appendUvarint(record, order.Time)
+				record = appendUvarint(record, order.Sequence)
+				writeBinaryRecord(bw, record)
+				return true
+			})
+			return true
+		})
+	}
+
+	//the errors of the bufio.Writer are sticky, Flush returns the first one
+	return bw.Flush()
+}
+
+func writeBinaryRecord(w *bufio.Writer, record []byte) {
+	w.Write(appendUvarint(nil, uint64(len(record))))
+	w.Write(record)
+}
+
+//DecodeBinary reads an order book written by EncodeBinary, along with its book time
+func DecodeBinary(r io.Reader) (*OrderBook, uint64, error) {
+	br := bufio.NewReader(r)
+
+	var prefix [len(binaryMagic) + 2]byte
+	if _, err := io.ReadFull(br, prefix[:]); err != nil {
+		return nil, 0, binaryError(err)
+	}
+	if string(prefix[:len(binaryMagic)]) != binaryMagic {
+		return nil, 0, fmt.Errorf("%w: magic %q", ErrBinaryFormat, prefix[:len(binaryMagic)])
+	}
+	if version := binary.BigEndian.Uint16(prefix[len(binaryMagic):]); version != BinaryVersion {
+		return nil, 0, fmt.Errorf("%w: unsupported version %d", ErrBinaryFormat, version)
+	}
+
+	header, err := readBinaryRecord(br)
+	if err != nil {
+		return nil, 0, err
+	}
+	var fields [4]uint64
+	hr := bytes.NewReader(header)
+	for i := range fields {
+		if fields[i], err = binary.ReadUvarint(hr); err != nil {
+			return nil, 0, binaryError(err)
+		}
+	}
+	if fields[0] > fixed.MaxDecimals || fields[1] > fixed.MaxDecimals {
+		return nil, 0, fmt.Errorf("%w: decimals %d, %d", ErrBinaryFormat, fields[0], fields[1])
+	}
+	priceScale, _ := fixed.NewScale(int32(fields[0]))
+	sizeScale, _ := fixed.NewScale(int32(fields[1]))
+
+	ob := NewOrderBook(priceScale, sizeScale)
+	ob.Sequence = fields[2]
+	for _, side := range []string{base.AskSide, base.BidSide} {
+		count, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, 0, binaryError(err)
+		}
+
+		for i := uint64(0); i < count; i++ {
+			record, err := readBinaryRecord(br)
+			if err != nil {
+				return nil, 0, err
+			}
+			order, err := decodeBinaryOrder(record, side)
+			if err != nil {
+				return nil, 0, err
+			}
+			if ob.GetOrder(order.OrderId) != nil {
+				return nil, 0, fmt.Errorf("%w: duplicate order %s", ErrBinaryFormat, order.OrderId)
+			}
+			if err := ob.AppendOrder(order); err != nil {
+				return nil, 0, err
+			}
+		}
+	}
+	ob.ResetChanges()
+
+	return ob, fields[3], nil
+}
+
+func readBinaryRecord(r *bufio.Reader) ([]byte, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, binaryError(err)
+	}
+	if n > maxBinaryRecordLen {
+		return nil, fmt.Errorf("%w: record length %d", ErrBinaryFormat, n)
+	}
+
+	record := make([]byte, n)
+	if _, err := io.ReadFull(r, record); err != nil {
+		return nil, binaryError(err)
+	}
+
+	return record, nil
+}
+
+func decodeBinaryOrder(record []byte, side string) (*Order, error) {
+	r := bytes.NewReader(record)
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, binaryError(err)
+	}
+	if n > uint64(r.Len()) {
+		return nil, fmt.Errorf("%w: orderId length %d", ErrBinaryFormat, n)
+	}
+	orderId := make([]byte, n)
+	r.Read(orderId)
+
+	price, err := binary.ReadVarint(r)
+	if err != nil {
+		return nil, binaryError(err)
+	}
+	size, err := binary.ReadVarint(r)
+	if err != nil {
+		return nil, binaryError(err)
+	}
+	if price < 0 || size < 0 {
+		return nil, fmt.Errorf("%w: order %s of negative price %d or size %d", ErrBinaryFormat, orderId, price, size)
+	}
+	time, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, binaryError(err)
+	}
+	sequence, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, binaryError(err)
+	}
+
+	order, err := NewOrder(string(orderId), side, price, size, time, nil)
+	if err != nil {
+		return nil, err
+	}
+	order.Sequence = sequence
+	return order, nil
+}
+
+//binaryError reports the data ending early as ErrBinaryFormat
+func binaryError(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return fmt.Errorf("%w: %v", ErrBinaryFormat, io.ErrUnexpectedEOF)
+	}
+	return err
+}
@@ -0,0 +1,113 @@
+package level3
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/base"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/fixed"
+)
+
+func TestBinaryRoundTrip(t *testing.T) {
+	price, _ := fixed.NewScale(2)
+	size, _ := fixed.NewScale(8)
+	ob := NewOrderBook(price, size)
+	ob.Sequence = 1234567
+	addTestOrder(t, ob, "a", base.BidSide, "10", "1", 1)
+	addTestOrder(t, ob, "b", base.BidSide, "10", "2.5", 1)
+	addTestOrder(t, ob, "c", base.BidSide, "10", "0.00000001", 0)
+	addTestOrder(t, ob, "d", base.BidSide, "9.99", "4", 1690000000000000000)
+	addTestOrder(t, ob, "e", base.AskSide, "11", "92233720368", 3)
+	addTestOrder(t, ob, "f", base.AskSide, "10.01", "5", 2)
+
+	var buf bytes.Buffer
+	if err := ob.View().EncodeBinary(&buf, 1690000000000000001); err != nil {
+		t.Fatal(err)
+	}
+	decoded, bookTime, err := DecodeBinary(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bookTime != 1690000000000000001 || decoded.PriceScale != price || decoded.SizeScale != size {
+		t.Errorf("unexpected header, book time: %d, scales: %+v, %+v", bookTime, decoded.PriceScale, decoded.SizeScale)
+	}
+
+	want, _ := ob.MarshalJSON()
+	got, _ := decoded.MarshalJSON()
+	if !bytes.Equal(got, want) {
+		t.Errorf("the decoded order book differs from the JSON form\ngot:  %s\nwant: %s", got, want)
+	}
+	if len(buf.Bytes()) >= len(want) {
+		t.Errorf("the binary form should be smaller than the JSON form, %d >= %d", len(buf.Bytes()), len(want))
+	}
+	if order := decoded.GetOrder("d"); order == nil || order.Time != 1690000000000000000 {
+		t.Errorf("the order time should be kept, got %+v", order)
+	}
+	order, err := ob.ParseOrder("g", base.AskSide, "10.01", "1", 2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	order.Sequence = 42
+	if err := ob.AddOrder(order); err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	if err := ob.View().EncodeBinary(&buf, 0); err != nil {
+		t.Fatal(err)
+	}
+	if decoded, _, err := DecodeBinary(&buf); err != nil || decoded.GetOrder("g").Sequence != 42 || decoded.GetOrder("f").Sequence != 0 {
+		t.Errorf("the order sequence should be kept, err: %v", err)
+	}
+
+	//an empty order book
+	buf.Reset()
+	if err := NewOrderBook(price, size).View().EncodeBinary(&buf, 0); err != nil {
+		t.Fatal(err)
+	}
+	if decoded, _, err := DecodeBinary(&buf); err != nil || decoded.Asks.Len() != 0 || decoded.Bids.Len() != 0 {
+		t.Errorf("the empty order book should round trip, err: %v", err)
+	}
+}
+
+func TestBinaryErrors(t *testing.T) {
+	price, _ := fixed.NewScale(2)
+	size, _ := fixed.NewScale(4)
+	ob := NewOrderBook(price, size)
+	addTestOrder(t, ob, "a", base.BidSide, "10", "1", 1)
+	addTestOrder(t, ob, "b", base.AskSide, "11", "1", 1)
+
+	var buf bytes.Buffer
+	if err := ob.View().EncodeBinary(&buf, 1); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	for i := 0; i < len(data); i++ {
+		if _, _, err := DecodeBinary(bytes.NewReader(data[:i])); !errors.Is(err, ErrBinaryFormat) {
+			t.Fatalf("the data truncated to %d bytes should be invalid, got %v", i, err)
+		}
+	}
+
+	wrong := append([]byte("KL3J"), data[4:]...)
+	if _, _, err := DecodeBinary(bytes.NewReader(wrong)); !errors.Is(err, ErrBinaryFormat) {
+		t.Errorf("the magic should be checked, got %v", err)
+	}
+	wrong = append([]byte{}, data...)
+	wrong[5] = BinaryVersion + 1
+	if _, _, err := DecodeBinary(bytes.NewReader(wrong)); !errors.Is(err, ErrBinaryFormat) {
+		t.Errorf("the version should be checked, got %v", err)
+	}
+
+	for _, o := range [][2]string{{"-10", "1"}, {"10", "-1"}} {
+		negative := NewOrderBook(price, size)
+		addTestOrder(t, negative, "a", base.BidSide, o[0], o[1], 1)
+		buf.Reset()
+		if err := negative.View().EncodeBinary(&buf, 1); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := DecodeBinary(&buf); !errors.Is(err, ErrBinaryFormat) {
+			t.Errorf("the negative price %s or size %s should be invalid, got %v", o[0], o[1], err)
+		}
+	}
+}
@@ -1,43 +1,54 @@
 package level3
 
 import (
-	"fmt"
-
 	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/base"
-	"github.com/shopspring/decimal"
 )
 
+//Order keeps the price and size as int64 scaled by the scales of the order book
 type Order struct {
 	OrderId string
 	Side    string
-	Price   decimal.Decimal
-	Size    decimal.Decimal
-	Time    uint64
+	Price   int64
+	Size    int64
+	Time    uint64 //the order time of the exchange, the queue priority
 	Info    interface{}
+
+	//the sequence of the message which opened the order, the queue priority among orders of the same time,
+	//0 for the orders of a snapshot
+	Sequence uint64
+
+	UpdateTime uint64 //the time of the latest change of the size, Time until the order changes
+	Fills      int    //partial fills seen by the order book
+
+	//the FIFO queue of the price level, rank increases along the queue
+	level      *PriceLevel
+	prev, next *Order
+	rank       int64
 }
 
-func NewOrder(orderId string, side string, price string, size string, time uint64, info interface{}) (order *Order, err error) {
+func NewOrder(orderId string, side string, price int64, size int64, time uint64, info interface{}) (order *Order, err error) {
 	if err := base.CheckSide(side); err != nil {
 		return nil, err
 	}
 
-	priceValue, err := decimal.NewFromString(price)
-	if err != nil {
-		return nil, fmt.Errorf("NewOrder failed, price: `%s`, error: %v", price, err)
-	}
-
-	sizeValue, err := decimal.NewFromString(size)
-	if err != nil {
-		return nil, fmt.Errorf("NewOrder failed, size: `%s`, error: %v", size, err)
-	}
-
 	order = &Order{
 		OrderId: orderId,
 		Side:    side,
-		Price:   priceValue,
-		Size:    sizeValue,
+		Price:   price,
+		Size:    size,
 		Time:    time,
 		Info:    info,
+
+		UpdateTime: time,
 	}
 	return
 }
+
+//before reports whether the order has priority over other
+func (order *Order) before(other *Order) bool {
+	if order.Time != other.Time {
+		return order.Time < other.Time
+	}
+
+	return order.Sequence < other.Sequence
+}
@@ -1,72 +1,101 @@
 package level3
 
 import (
-	"encoding/json"
 	"fmt"
 
 	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/base"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/fixed"
 	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/skiplist"
-	"github.com/shopspring/decimal"
 )
 
+//OrderBook keeps prices and sizes as int64 of PriceScale and SizeScale,
+//they are formatted to strings only by the output methods
 type OrderBook struct {
-	Sequence  uint64
-	Asks      *skiplist.SkipList //Sort price from low to high
-	Bids      *skiplist.SkipList //Sort price from high to low
-	orderPool map[string]*Order
+	Sequence   uint64
+	Asks       *levelList //Sort price from low to high
+	Bids       *levelList //Sort price from high to low
+	orderPool  map[string]*Order
+	PriceScale fixed.Scale
+	SizeScale  fixed.Scale
+
+	askChange priceChange
+	bidChange priceChange
+
+	//the price levels and orders changed since the last view, which is derived again by copying only the changed orders
+	changedAsks   map[int64]struct{}
+	changedBids   map[int64]struct{}
+	changedOrders map[string]struct{}
+	view          *View
 }
 
-func NewOrderBook() *OrderBook {
+//priceChange is the best price changed on a side since ResetChanges
+type priceChange struct {
+	price   int64
+	changed bool
+}
+
+func NewOrderBook(priceScale, sizeScale fixed.Scale) *OrderBook {
 	return &OrderBook{
-		Asks:      newAskOrders(),
-		Bids:      newBidOrders(),
-		orderPool: make(map[string]*Order),
+		Asks:       newAskLevels(),
+		Bids:       newBidLevels(),
+		orderPool:  make(map[string]*Order),
+		PriceScale: priceScale,
+		SizeScale:  sizeScale,
+
+		changedAsks:   make(map[int64]struct{}),
+		changedBids:   make(map[int64]struct{}),
+		changedOrders: make(map[string]struct{}),
 	}
 }
 
-func isEqual(l, r interface{}) bool {
-	switch val := l.(type) {
-	case decimal.Decimal:
-		cVal := r.(decimal.Decimal)
-		if !val.Equals(cVal) {
-			return false
-		}
-
-	case *Order:
-		cVal := r.(*Order)
-		if cVal.OrderId != val.OrderId {
-			return false
-		}
+//levelList sorts the price levels of a side by price
+type levelList = skiplist.SkipList[int64, *PriceLevel]
 
-	default:
-		if val != r {
-			return false
-		}
-	}
-	return true
+func isEqual(l, r int64) bool {
+	return l == r
 }
 
-func newAskOrders() *skiplist.SkipList {
-	return skiplist.NewCustomMap(func(l, r interface{}) bool {
-		if l.(*Order).Price.Equal(r.(*Order).Price) {
-			return l.(*Order).Time < r.(*Order).Time
-		}
+func newAskLevels() *levelList {
+	return skiplist.NewCustomMap[int64, *PriceLevel](func(l, r int64) bool {
+		return l < r
+	}, isEqual)
+}
 
-		return l.(*Order).Price.LessThan(r.(*Order).Price)
+func newBidLevels() *levelList {
+	return skiplist.NewCustomMap[int64, *PriceLevel](func(l, r int64) bool {
+		return l > r
 	}, isEqual)
 }
 
-func newBidOrders() *skiplist.SkipList {
-	return skiplist.NewCustomMap(func(l, r interface{}) bool {
-		if l.(*Order).Price.Equal(r.(*Order).Price) {
-			return l.(*Order).Time < r.(*Order).Time
-		}
+//ParseOrder creates an order with the price and size strings in the scales of the order book
+func (ob *OrderBook) ParseOrder(orderId string, side string, price string, size string, time uint64, info interface{}) (*Order, error) {
+	priceValue, err := ob.PriceScale.Parse(price)
+	if err != nil {
+		return nil, fmt.Errorf("NewOrder failed, price: `%s`, error: %w", price, err)
+	}
 
-		return l.(*Order).Price.GreaterThan(r.(*Order).Price)
-	}, isEqual)
+	sizeValue, err := ob.SizeScale.Parse(size)
+	if err != nil {
+		return nil, fmt.Errorf("NewOrder failed, size: `%s`, error: %w", size, err)
+	}
+
+	return NewOrder(orderId, side, priceValue, sizeValue, time, info)
+}
+
+//ParseSize parses a size string in the size scale
+func (ob *OrderBook) ParseSize(size string) (int64, error) {
+	return ob.SizeScale.Parse(size)
 }
 
-func (ob *OrderBook) getOrderBookBySide(side string) (*skiplist.SkipList, error) {
+func (ob *OrderBook) FormatPrice(price int64) string {
+	return ob.PriceScale.Format(price)
+}
+
+func (ob *OrderBook) FormatSize(size int64) string {
+	return ob.SizeScale.Format(size)
+}
+
+func (ob *OrderBook) getOrderBookBySide(side string) (*levelList, error) {
 	if err := base.CheckSide(side); err != nil {
 		return nil, err
 	}
@@ -78,14 +107,48 @@ func (ob *OrderBook) getOrderBookBySide(side string) (*skiplist.SkipList, error)
 	return ob.Bids, nil
 }
 
+//AddOrder queues the order by its Time and Sequence in its price level, an order with the same orderId is replaced
 func (ob *OrderBook) AddOrder(order *Order) error {
-	orderBook, err := ob.getOrderBookBySide(order.Side)
+	return ob.addOrder(order, (*PriceLevel).insert)
+}
+
+//AppendOrder queues the order at the tail of its price level, so the orders of a snapshot keep the queue of the exchange
+func (ob *OrderBook) AppendOrder(order *Order) error {
+	return ob.addOrder(order, (*PriceLevel).push)
+}
+
+func (ob *OrderBook) addOrder(order *Order, queue func(level *PriceLevel, order *Order) error) error {
+	levels, err := ob.getOrderBookBySide(order.Side)
 	if err != nil {
 		return err
 	}
 
-	orderBook.Set(order, order)
+	if old, ok := ob.orderPool[order.OrderId]; ok {
+		if err := ob.removeOrder(old); err != nil {
+			return err
+		}
+	}
+
+	level, ok := levels.Get(order.Price)
+	if !ok {
+		level = newPriceLevel(order.Price)
+		levels.Set(order.Price, level)
+	}
+	if err := queue(level, order); err != nil {
+		if level.Count == 0 {
+			levels.Delete(level.Price)
+		}
+		return err
+	}
 	ob.orderPool[order.OrderId] = order
+	ob.changedOrders[order.OrderId] = struct{}{}
+	if level.renumbered {
+		level.renumbered = false
+		for o := level.head; o != nil; o = o.next {
+			ob.changedOrders[o.OrderId] = struct{}{}
+		}
+	}
+	ob.touch(order.Side, order.Price)
 	return nil
 }
 
@@ -102,13 +165,19 @@ func (ob *OrderBook) RemoveByOrderId(orderId string) error {
 }
 
 func (ob *OrderBook) removeOrder(order *Order) error {
-	orderBook, err := ob.getOrderBookBySide(order.Side)
+	levels, err := ob.getOrderBookBySide(order.Side)
 	if err != nil {
 		return err
 	}
-	if _, ok := orderBook.Delete(order); ok {
-		delete(ob.orderPool, order.OrderId)
+
+	level := order.level
+	level.remove(order)
+	if level.Count == 0 {
+		levels.Delete(level.Price)
 	}
+	delete(ob.orderPool, order.OrderId)
+	ob.changedOrders[order.OrderId] = struct{}{}
+	ob.touch(order.Side, level.Price)
 
 	return nil
 }
@@ -122,152 +191,108 @@ func (ob *OrderBook) GetOrder(orderId string) *Order {
 	return order
 }
 
-func (ob *OrderBook) MatchOrder(orderId string, size decimal.Decimal) error {
+//MatchOrder fills size of the order at time
+func (ob *OrderBook) MatchOrder(orderId string, size int64, time uint64) error {
 	order, ok := ob.orderPool[orderId]
 	if !ok {
 		return nil
 	}
 
-	newSize := order.Size.Sub(size)
-	if newSize.LessThan(decimal.Zero) {
-		return fmt.Errorf("oldSize: %s, size: %s, sub result less than zero", order.Size.String(), size)
+	newSize := order.Size - size
+	if newSize < 0 || size < 0 {
+		return fmt.Errorf("oldSize: %s, size: %s, sub result less than zero", ob.FormatSize(order.Size), ob.FormatSize(size))
 	}
 
-	order.Size = newSize
-	if order.Size.Equal(decimal.Zero) {
-		if err := ob.removeOrder(order); err != nil {
-			return err
-		}
+	return ob.FillOrder(orderId, newSize, time)
+}
+
+//FillOrder fills the order at time, leaving the remaining size
+func (ob *OrderBook) FillOrder(orderId string, remainSize int64, time uint64) error {
+	order, ok := ob.orderPool[orderId]
+	if !ok {
 		return nil
 	}
 
-	return nil
+	order.Fills++
+	return ob.ChangeOrder(orderId, remainSize, time)
 }
 
-func (ob *OrderBook) ChangeOrder(orderId string, size decimal.Decimal) error {
+//ChangeOrder changes the size of the order at time and keeps its priority, the order is removed at size zero
+func (ob *OrderBook) ChangeOrder(orderId string, size int64, time uint64) error {
 	order, ok := ob.orderPool[orderId]
 	if !ok {
 		return nil
 	}
+	order.UpdateTime = time
 
-	order.Size = size
-	if order.Size.Equal(decimal.Zero) {
+	if size == 0 {
 		if err := ob.removeOrder(order); err != nil {
 			return err
 		}
+		order.Size = size
 		return nil
 	}
-	return nil
-}
 
-func (ob *OrderBook) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
-		"sequence":   ob.Sequence,
-		base.AskSide: ob.GetL3PartOrderBookBySide(base.AskSide, 0),
-		base.BidSide: ob.GetL3PartOrderBookBySide(base.BidSide, 0),
-	})
+	ob.changedOrders[order.OrderId] = struct{}{}
+	ob.touch(order.Side, order.Price)
+	return order.level.resize(order, size)
 }
 
-// Level3 OrderBook
-func (ob *OrderBook) GetL3PartOrderBookBySide(side string, number int) [][3]string {
-	if err := base.CheckSide(side); err != nil {
-		return nil
-	}
-
-	var it skiplist.Iterator
+//touch records the change of the price level for the best change and the next view
+func (ob *OrderBook) touch(side string, price int64) {
 	if side == base.AskSide {
-		it = ob.Asks.Iterator()
-		if number == 0 {
-			number = ob.Asks.Len()
-		} else {
-			number = base.Min(number, ob.Asks.Len())
-		}
-	} else {
-		it = ob.Bids.Iterator()
-		if number == 0 {
-			number = ob.Bids.Len()
-		} else {
-			number = base.Min(number, ob.Bids.Len())
+		ob.changedAsks[price] = struct{}{}
+		if !ob.askChange.changed || price < ob.askChange.price {
+			ob.askChange = priceChange{price: price, changed: true}
 		}
+		return
 	}
-	arr := make([][3]string, 0, number)
 
-	for it.Next() {
-		if len(arr) >= number {
-			break
-		}
-
-		order := it.Value().(*Order)
-		arr = append(arr, [3]string{order.OrderId, order.Price.String(), order.Size.String()})
+	ob.changedBids[price] = struct{}{}
+	if !ob.bidChange.changed || price > ob.bidChange.price {
+		ob.bidChange = priceChange{price: price, changed: true}
 	}
-
-	return arr
 }
 
-// Level2 OrderBook
-func (ob *OrderBook) GetPartOrderBookBySide(side string, number int) [][2]string {
-	if err := base.CheckSide(side); err != nil {
-		return nil
-	}
-
-	var it skiplist.Iterator
+//BestChange returns the best price of the side changed since ResetChanges
+func (ob *OrderBook) BestChange(side string) (price int64, changed bool) {
 	if side == base.AskSide {
-		it = ob.Asks.Iterator()
-		if number == 0 {
-			number = ob.Asks.Len()
-		} else {
-			number = base.Min(number, ob.Asks.Len())
-		}
-	} else {
-		it = ob.Bids.Iterator()
-		if number == 0 {
-			number = ob.Bids.Len()
-		} else {
-			number = base.Min(number, ob.Bids.Len())
-		}
+		return ob.askChange.price, ob.askChange.changed
 	}
-	arr := make([][2]string, 0, number)
 
-	lastPrice := decimal.Zero
-	lastPriceSize := decimal.Zero
+	return ob.bidChange.price, ob.bidChange.changed
+}
 
-	for it.Next() {
-		if len(arr) >= number {
-			break
-		}
+//ResetChanges forgets the changed prices
+func (ob *OrderBook) ResetChanges() {
+	ob.askChange = priceChange{}
+	ob.bidChange = priceChange{}
+}
 
-		order := it.Value().(*Order)
-		if lastPrice.Equal(decimal.Zero) {
-			lastPrice = order.Price
-			lastPriceSize = order.Size
-			continue
-		}
-		if lastPrice.Equal(order.Price) {
-			lastPriceSize = lastPriceSize.Add(order.Size)
-			continue
-		}
-		arr = append(arr, [2]string{lastPrice.String(), lastPriceSize.String()})
-		lastPrice = order.Price
-		lastPriceSize = order.Size
+//GetPriceLevel returns the price level of the side, nil if there is no order at the price
+func (ob *OrderBook) GetPriceLevel(side string, price int64) *PriceLevel {
+	levels, err := ob.getOrderBookBySide(side)
+	if err != nil {
+		return nil
 	}
 
-	return arr
+	level, _ := levels.Get(price)
+	return level
+}
+
+//MarshalJSON marshals the view of the order book, the caller owns the order book
+func (ob *OrderBook) MarshalJSON() ([]byte, error) {
+	return ob.View().MarshalJSON()
 }
 
 func (ob *OrderBook) GetOrderBookTickerOrder() (askOrder, bidOrder *Order) {
 	askIT := ob.Asks.Iterator()
-	askIT.Next()
-	ask := askIT.Value()
-	switch ask.(type) {
-	case *Order:
-		askOrder = ask.(*Order)
+	if askIT.Next() {
+		askOrder = askIT.Value().Front()
 	}
 	bidIT := ob.Bids.Iterator()
-	bidIT.Next()
-	bid := bidIT.Value()
-	switch bid.(type) {
-	case *Order:
-		bidOrder = bid.(*Order)
+	if bidIT.Next() {
+		bidOrder = bidIT.Value().Front()
 	}
 	return
 }
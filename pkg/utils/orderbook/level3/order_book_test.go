@@ -0,0 +1,247 @@
+package level3
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/base"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/fixed"
+)
+
+func addTestOrder(t *testing.T, ob *OrderBook, orderId, side, price, size string, time uint64) {
+	order, err := ob.ParseOrder(orderId, side, price, size, time, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ob.AddOrder(order); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPriceLevelQueue(t *testing.T) {
+	price, _ := fixed.NewScale(2)
+	size, _ := fixed.NewScale(4)
+	ob := NewOrderBook(price, size)
+	//the same price and time used to collide when the orders were keyed by (price, time)
+	addTestOrder(t, ob, "a", base.BidSide, "10", "1", 1)
+	addTestOrder(t, ob, "b", base.BidSide, "10", "2", 1)
+	addTestOrder(t, ob, "c", base.BidSide, "10", "3", 0)
+	addTestOrder(t, ob, "d", base.BidSide, "9", "4", 2)
+	addTestOrder(t, ob, "e", base.AskSide, "11", "5", 3)
+
+	want := [][3]string{{"c", "10", "3"}, {"a", "10", "1"}, {"b", "10", "2"}, {"d", "9", "4"}}
+	before := ob.View()
+	if got := before.GetL3PartOrderBookBySide(base.BidSide, 0); !reflect.DeepEqual(got, want) {
+		t.Errorf("bids should be queued by time, then by arrival, got %v", got)
+	}
+
+	level := ob.GetPriceLevel(base.BidSide, 1000)
+	if level == nil || level.Count != 3 || ob.FormatSize(level.Size) != "6" {
+		t.Fatalf("unexpected price level: %+v", level)
+	}
+
+	if err := ob.MatchOrder("a", 5000, 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := ob.ChangeOrder("b", 0, 11); err != nil {
+		t.Fatal(err)
+	}
+	if err := ob.RemoveByOrderId("d"); err != nil {
+		t.Fatal(err)
+	}
+
+	if level.Count != 2 || ob.FormatSize(level.Size) != "3.5" || level.Front().OrderId != "c" || level.Front().Next().OrderId != "a" {
+		t.Errorf("unexpected price level after changes: %+v", level)
+	}
+	if got := ob.View().GetPartOrderBookBySide(base.BidSide, 0); !reflect.DeepEqual(got, [][2]string{{"10", "3.5"}}) {
+		t.Errorf("unexpected bids: %v", got)
+	}
+	if ob.GetPriceLevel(base.BidSide, 900) != nil {
+		t.Errorf("the empty price level should be removed")
+	}
+
+	addTestOrder(t, ob, "f", base.BidSide, "10", "2", 4)
+	order, position, ok := ob.View().QueuePosition("f")
+	if !ok || order.OrderId != "f" || position != (QueuePosition{OrdersAhead: 2, SizeAhead: 35000, LevelCount: 3, LevelSize: 55000}) {
+		t.Errorf("unexpected queue position: %+v", position)
+	}
+	if _, _, ok := ob.View().QueuePosition("b"); ok {
+		t.Errorf("the removed order should have no queue position")
+	}
+	if got := before.GetL3PartOrderBookBySide(base.BidSide, 0); !reflect.DeepEqual(got, want) {
+		t.Errorf("the view should not be changed by the later changes, got %v", got)
+	}
+	if _, position, ok := before.QueuePosition("b"); !ok || position.OrdersAhead != 2 || before.GetOrder("b").Size != 20000 {
+		t.Errorf("the view should keep the removed order, got %+v", position)
+	}
+
+	ask, bid := ob.GetOrderBookTickerOrder()
+	if ask.OrderId != "e" || bid.OrderId != "c" {
+		t.Errorf("unexpected ticker: %s, %s", ask.OrderId, bid.OrderId)
+	}
+}
+
+func TestQueuePriority(t *testing.T) {
+	price, _ := fixed.NewScale(2)
+	size, _ := fixed.NewScale(4)
+	ob := NewOrderBook(price, size)
+
+	//a snapshot keeps the queue of the exchange
+	for _, o := range []struct {
+		orderId string
+		time    uint64
+	}{{"s1", 5}, {"s2", 3}, {"s3", 7}} {
+		order, err := ob.ParseOrder(o.orderId, base.AskSide, "10", "1", o.time, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ob.AppendOrder(order); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	//the stream orders are queued by time and by sequence among the same time
+	for _, o := range []struct {
+		orderId  string
+		time     uint64
+		sequence uint64
+	}{{"b", 7, 12}, {"a", 7, 11}, {"c", 6, 13}, {"d", 9, 14}, {"e", 1, 15}} {
+		order, err := ob.ParseOrder(o.orderId, base.AskSide, "10", "1", o.time, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		order.Sequence = o.sequence
+		if err := ob.AddOrder(order); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got []string
+	for order := ob.GetPriceLevel(base.AskSide, 1000).Front(); order != nil; order = order.Next() {
+		got = append(got, order.OrderId)
+	}
+	if want := []string{"e", "s1", "s2", "c", "s3", "a", "b", "d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected queue: %v, want %v", got, want)
+	}
+}
+
+//TestLevelViewSharing checks a view copies the changed orders only, and keeps the queue when the ranks are renumbered
+func TestLevelViewSharing(t *testing.T) {
+	price, _ := fixed.NewScale(2)
+	size, _ := fixed.NewScale(4)
+	ob := NewOrderBook(price, size)
+	addTestOrder(t, ob, "x", base.BidSide, "10", "1", 0)
+	addTestOrder(t, ob, "y", base.BidSide, "10", "1", 100)
+	addTestOrder(t, ob, "z", base.BidSide, "9", "1", 0)
+
+	//each order is queued right after x, halving the room between the ranks until they are renumbered
+	for i := 0; i < 40; i++ {
+		before := ob.View()
+		order, err := ob.ParseOrder(strconv.Itoa(i), base.BidSide, "10", "1", 50, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		order.Sequence = uint64(100 - i)
+		if err := ob.AddOrder(order); err != nil {
+			t.Fatal(err)
+		}
+
+		var want [][3]string
+		for order := ob.GetPriceLevel(base.BidSide, 1000).Front(); order != nil; order = order.Next() {
+			want = append(want, [3]string{order.OrderId, "10", "1"})
+		}
+		want = append(want, [3]string{"z", "9", "1"})
+		view := ob.View()
+		if got := view.GetL3PartOrderBookBySide(base.BidSide, 0); !reflect.DeepEqual(got, want) {
+			t.Fatalf("the view should keep the queue after %d orders, got %v, want %v", i+1, got, want)
+		}
+		if _, position, _ := view.QueuePosition(strconv.Itoa(i)); position.OrdersAhead != 1 {
+			t.Errorf("the order %d should be queued after x, got %+v", i, position)
+		}
+		if len(before.GetL3PartOrderBookBySide(base.BidSide, 0)) != i+3 {
+			t.Errorf("the previous view should not be changed")
+		}
+		if before.GetPriceLevel(base.BidSide, 900) != view.GetPriceLevel(base.BidSide, 900) || before.GetOrder("z") != view.GetOrder("z") {
+			t.Errorf("the unchanged price level should be shared")
+		}
+	}
+
+	before := ob.View()
+	if err := ob.ChangeOrder("y", 5000, 1); err != nil {
+		t.Fatal(err)
+	}
+	view := ob.View()
+	if before.GetOrder("x") != view.GetOrder("x") || before.GetOrder("y") == view.GetOrder("y") {
+		t.Errorf("only the changed order should be copied")
+	}
+	if got := view.GetOrder("y").Size; got != 5000 || before.GetOrder("y").Size != 10000 {
+		t.Errorf("the changed order should be copied, got %d", got)
+	}
+	if level := view.GetPriceLevel(base.BidSide, 1000); level.Count != 42 || level.Size != 415000 {
+		t.Errorf("unexpected price level: %d, %d", level.Count, level.Size)
+	}
+}
+
+func TestLevelsBetweenAndCovering(t *testing.T) {
+	price, _ := fixed.NewScale(2)
+	size, _ := fixed.NewScale(4)
+	ob := NewOrderBook(price, size)
+	addTestOrder(t, ob, "a", base.AskSide, "11", "1", 0)
+	addTestOrder(t, ob, "b", base.AskSide, "12", "2", 1)
+	addTestOrder(t, ob, "c", base.AskSide, "12", "3", 2)
+	addTestOrder(t, ob, "d", base.AskSide, "13", "4", 3)
+	addTestOrder(t, ob, "e", base.BidSide, "10", "5", 4)
+	addTestOrder(t, ob, "f", base.BidSide, "9", "6", 5)
+	view := ob.View()
+
+	if got := view.FormatLevels(view.LevelsBetween(base.AskSide, 1100, 1200)); !reflect.DeepEqual(got, [][2]string{{"11", "1"}, {"12", "5"}}) {
+		t.Errorf("unexpected asks between: %v", got)
+	}
+	if got := view.FormatLevels(view.LevelsBetween(base.BidSide, 900, 1000)); !reflect.DeepEqual(got, [][2]string{{"10", "5"}, {"9", "6"}}) {
+		t.Errorf("unexpected bids between: %v", got)
+	}
+	if got := view.LevelsBetween(base.BidSide, 950, 990); len(got) != 0 {
+		t.Errorf("unexpected bids between: %v", view.FormatLevels(got))
+	}
+
+	want := [][3]string{{"a", "11", "1"}, {"b", "12", "2"}, {"c", "12", "3"}}
+	if got := view.FormatLevelOrders(view.LevelsCovering(base.AskSide, 20000)); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected asks covering: %v", got)
+	}
+	if got := view.FormatLevels(view.LevelsCovering(base.BidSide, 1000000)); !reflect.DeepEqual(got, [][2]string{{"10", "5"}, {"9", "6"}}) {
+		t.Errorf("the levels covering more than the side should be all levels: %v", got)
+	}
+}
+
+func TestAggregateLevels(t *testing.T) {
+	price, _ := fixed.NewScale(2)
+	size, _ := fixed.NewScale(4)
+	ob := NewOrderBook(price, size)
+	addTestOrder(t, ob, "a", base.AskSide, "10.01", "1", 0)
+	addTestOrder(t, ob, "b", base.AskSide, "10.1", "2", 1)
+	addTestOrder(t, ob, "c", base.AskSide, "10.1", "3", 2)
+	addTestOrder(t, ob, "d", base.AskSide, "10.11", "4", 3)
+	addTestOrder(t, ob, "e", base.BidSide, "9.99", "5", 4)
+	addTestOrder(t, ob, "f", base.BidSide, "9.9", "6", 5)
+	addTestOrder(t, ob, "g", base.BidSide, "9.89", "7", 6)
+	view := ob.View()
+
+	want := [][3]string{{"10.1", "6", "3"}, {"10.2", "4", "1"}}
+	if got := view.AggregateLevels(base.AskSide, 10, 0); !reflect.DeepEqual(got, want) {
+		t.Errorf("asks should be rounded up, got %v", got)
+	}
+
+	want = [][3]string{{"9.9", "11", "2"}, {"9.8", "7", "1"}}
+	if got := view.AggregateLevels(base.BidSide, 10, 0); !reflect.DeepEqual(got, want) {
+		t.Errorf("bids should be rounded down, got %v", got)
+	}
+
+	want = [][3]string{{"9", "18", "3"}}
+	if got := view.AggregateLevels(base.BidSide, 100, 1); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected bids, got %v", got)
+	}
+	if got := view.AggregateLevels(base.AskSide, 1, 2); !reflect.DeepEqual(got, [][3]string{{"10.01", "1", "1"}, {"10.1", "5", "2"}}) {
+		t.Errorf("the step of a tick should be the levels, got %v", got)
+	}
+}
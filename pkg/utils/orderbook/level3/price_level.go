@@ -0,0 +1,143 @@
+package level3
+
+import (
+	"math"
+
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/fixed"
+)
+
+//rankGap spaces the ranks of the orders queued at the head or the tail, leaving room for the orders queued between them
+const rankGap = 1 << 20
+
+//PriceLevel queues the orders of a price by priority, with the running total size and order count
+type PriceLevel struct {
+	Price int64
+	Size  int64
+	Count int
+
+	head, tail *Order
+
+	//the ranks of all orders were renumbered since the order book last looked
+	renumbered bool
+}
+
+func newPriceLevel(price int64) *PriceLevel {
+	return &PriceLevel{
+		Price: price,
+	}
+}
+
+//push appends the order to the tail of the queue
+func (l *PriceLevel) push(order *Order) error {
+	return l.insertAfter(order, l.tail)
+}
+
+//insert queues the order by its priority, after the orders of the same priority,
+//walking from the tail as the orders mostly arrive in the order of priority
+func (l *PriceLevel) insert(order *Order) error {
+	prev := l.tail
+	for prev != nil && order.before(prev) {
+		prev = prev.prev
+	}
+
+	return l.insertAfter(order, prev)
+}
+
+//insertAfter links the order after prev, at the head if prev is nil
+func (l *PriceLevel) insertAfter(order *Order, prev *Order) error {
+	size, err := fixed.Add(l.Size, order.Size)
+	if err != nil {
+		return err
+	}
+
+	order.level = l
+	order.prev = prev
+	if prev == nil {
+		order.next = l.head
+		l.head = order
+	} else {
+		order.next = prev.next
+		prev.next = order
+	}
+	if order.next == nil {
+		l.tail = order
+	} else {
+		order.next.prev = order
+	}
+
+	l.rank(order)
+	l.Size = size
+	l.Count++
+	return nil
+}
+
+//rank keys the linked order in the queue of the level view between its neighbours,
+//all orders are renumbered when there is no room between them
+func (l *PriceLevel) rank(order *Order) {
+	switch prev, next := order.prev, order.next; {
+	case prev == nil && next == nil:
+		order.rank = 0
+		return
+	case prev == nil:
+		if next.rank >= math.MinInt64+rankGap {
+			order.rank = next.rank - rankGap
+			return
+		}
+	case next == nil:
+		if prev.rank <= math.MaxInt64-rankGap {
+			order.rank = prev.rank + rankGap
+			return
+		}
+	case next.rank-prev.rank > 1:
+		order.rank = prev.rank + (next.rank-prev.rank)/2
+		return
+	}
+
+	rank := int64(0)
+	for o := l.head; o != nil; o = o.next {
+		o.rank = rank
+		rank += rankGap
+	}
+	l.renumbered = true
+}
+
+//remove unlinks the order from the queue
+func (l *PriceLevel) remove(order *Order) {
+	if order.prev == nil {
+		l.head = order.next
+	} else {
+		order.prev.next = order.next
+	}
+	if order.next == nil {
+		l.tail = order.prev
+	} else {
+		order.next.prev = order.prev
+	}
+	order.level, order.prev, order.next = nil, nil, nil
+
+	//never overflows, the size of the level includes the size of the order
+	l.Size -= order.Size
+	l.Count--
+}
+
+//resize changes the size of a queued order without losing its priority
+func (l *PriceLevel) resize(order *Order, size int64) error {
+	levelSize, err := fixed.Add(l.Size-order.Size, size)
+	if err != nil {
+		return err
+	}
+
+	l.Size = levelSize
+	order.Size = size
+	return nil
+}
+
+//Front returns the order with the highest priority
+func (l *PriceLevel) Front() *Order {
+	return l.head
+}
+
+//Next returns the order queued after order, nil at the tail
+func (order *Order) Next() *Order {
+	return order.next
+}
@@ -0,0 +1,421 @@
+package level3
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/base"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/fixed"
+	"github.com/Kucoin/kucoin-level3-sdk/pkg/utils/orderbook/treap"
+)
+
+//View is an immutable snapshot of the order book, it is derived from the previous view by copying
+//the changed orders only, the unchanged orders and price levels are shared, so any goroutine reads a view without locking
+type View struct {
+	Sequence   uint64
+	PriceScale fixed.Scale
+	SizeScale  fixed.Scale
+
+	asks   *treap.Map[int64, *LevelView] //Sort price from low to high
+	bids   *treap.Map[int64, *LevelView] //Sort price from high to low
+	orders *treap.Map[string, orderLevel]
+}
+
+//LevelView is a copy of a price level, the orders are copied without their links and keyed by their ranks,
+//so Next of an order of the view is always nil, and a change of an order copies O(log n) nodes of the queue
+type LevelView struct {
+	Price int64
+	Size  int64
+	Count int
+
+	orders *treap.Map[int64, *Order]
+}
+
+//orderLevel locates an order in the queue of its price level
+type orderLevel struct {
+	side  string
+	price int64
+	rank  int64
+}
+
+//QueuePosition is the position of an order in the queue of its price level
+type QueuePosition struct {
+	OrdersAhead int
+	SizeAhead   int64
+	LevelCount  int
+	LevelSize   int64
+}
+
+func lessString(l, r string) bool {
+	return l < r
+}
+
+func newView(priceScale, sizeScale fixed.Scale) *View {
+	return &View{
+		PriceScale: priceScale,
+		SizeScale:  sizeScale,
+		asks: treap.NewCustomMap[int64, *LevelView](func(l, r int64) bool {
+			return l < r
+		}),
+		bids: treap.NewCustomMap[int64, *LevelView](func(l, r int64) bool {
+			return l > r
+		}),
+		orders: treap.NewCustomMap[string, orderLevel](lessString),
+	}
+}
+
+//View returns the view of the order book, the caller owns the order book,
+//the view is derived again only if the order book changed since the last call
+func (ob *OrderBook) View() *View {
+	last := ob.view
+	if last != nil && last.Sequence == ob.Sequence && len(ob.changedOrders) == 0 && len(ob.changedAsks) == 0 && len(ob.changedBids) == 0 {
+		return last
+	}
+
+	var view *View
+	if last != nil {
+		copied := *last
+		view = &copied
+	} else {
+		view = newView(ob.PriceScale, ob.SizeScale)
+	}
+	view.Sequence = ob.Sequence
+
+	//the old copies of the changed orders are removed before the new ones are keyed, a rank may be reused in the level
+	levels := map[orderLevel]*LevelView{}
+	for orderId := range ob.changedOrders {
+		if located, ok := view.orders.Get(orderId); ok {
+			level := view.copyLevel(levels, located.side, located.price)
+			level.orders = level.orders.Delete(located.rank)
+		}
+	}
+	for orderId := range ob.changedOrders {
+		if order, ok := ob.orderPool[orderId]; ok {
+			level := view.copyLevel(levels, order.Side, order.Price)
+			copied := *order
+			copied.level, copied.prev, copied.next = nil, nil, nil
+			level.orders = level.orders.Set(order.rank, &copied)
+			//a change of the size keeps the location
+			located := orderLevel{side: order.Side, price: order.Price, rank: order.rank}
+			if old, ok := view.orders.Get(orderId); !ok || old != located {
+				view.orders = view.orders.Set(orderId, located)
+			}
+		} else {
+			view.orders = view.orders.Delete(orderId)
+		}
+		delete(ob.changedOrders, orderId)
+	}
+
+	view.asks = setLevels(view.asks, ob.Asks, ob.changedAsks, levels, base.AskSide)
+	view.bids = setLevels(view.bids, ob.Bids, ob.changedBids, levels, base.BidSide)
+	ob.view = view
+	return view
+}
+
+//copyLevel returns the copy of the price level of the view being derived, copied once for all changed orders of the level
+func (v *View) copyLevel(levels map[orderLevel]*LevelView, side string, price int64) *LevelView {
+	key := orderLevel{side: side, price: price}
+	if level, ok := levels[key]; ok {
+		return level
+	}
+
+	level := &LevelView{Price: price, orders: treap.NewCustomMap[int64, *Order](lessInt64)}
+	if old, ok := v.levels(side).Get(price); ok {
+		copied := *old
+		level = &copied
+	}
+	levels[key] = level
+	return level
+}
+
+//setLevels sets the size and count of the changed price levels into the copies, which replace the levels of the view,
+//and forgets the changes
+func setLevels(views *treap.Map[int64, *LevelView], levels *levelList, changed map[int64]struct{}, copies map[orderLevel]*LevelView, side string) *treap.Map[int64, *LevelView] {
+	for price := range changed {
+		level, ok := levels.Get(price)
+		if !ok {
+			views = views.Delete(price)
+			delete(changed, price)
+			continue
+		}
+
+		copied, ok := copies[orderLevel{side: side, price: price}]
+		if !ok {
+			old, _ := views.Get(price)
+			view := *old
+			copied = &view
+		}
+		copied.Size, copied.Count = level.Size, level.Count
+		views = views.Set(price, copied)
+		delete(changed, price)
+	}
+
+	return views
+}
+
+func lessInt64(l, r int64) bool {
+	return l < r
+}
+
+//RangeOrders calls fn with the orders of the level in the order of the queue until fn returns false
+func (l *LevelView) RangeOrders(fn func(order *Order) bool) {
+	l.orders.Ascend(func(rank int64, order *Order) bool {
+		return fn(order)
+	})
+}
+
+func (v *View) FormatPrice(price int64) string {
+	return v.PriceScale.Format(price)
+}
+
+func (v *View) FormatSize(size int64) string {
+	return v.SizeScale.Format(size)
+}
+
+func (v *View) levels(side string) *treap.Map[int64, *LevelView] {
+	if side == base.AskSide {
+		return v.asks
+	}
+
+	return v.bids
+}
+
+//RangeLevels calls fn with the price levels of the side from the best price until fn returns false
+func (v *View) RangeLevels(side string, fn func(level *LevelView) bool) {
+	if err := base.CheckSide(side); err != nil {
+		return
+	}
+
+	v.levels(side).Ascend(func(price int64, level *LevelView) bool {
+		return fn(level)
+	})
+}
+
+//GetPriceLevel returns the price level of the side, nil if there is no order at the price
+func (v *View) GetPriceLevel(side string, price int64) *LevelView {
+	if err := base.CheckSide(side); err != nil {
+		return nil
+	}
+
+	level, _ := v.levels(side).Get(price)
+	return level
+}
+
+//QueuePosition returns the order and its position in the queue of its price level
+func (v *View) QueuePosition(orderId string) (*Order, QueuePosition, bool) {
+	located, ok := v.orders.Get(orderId)
+	if !ok {
+		return nil, QueuePosition{}, false
+	}
+
+	level := v.GetPriceLevel(located.side, located.price)
+	order, _ := level.orders.Get(located.rank)
+	position := QueuePosition{
+		LevelCount: level.Count,
+		LevelSize:  level.Size,
+	}
+	level.orders.Ascend(func(rank int64, ahead *Order) bool {
+		if rank >= located.rank {
+			return false
+		}
+		position.OrdersAhead++
+		position.SizeAhead += ahead.Size
+		return true
+	})
+
+	return order, position, true
+}
+
+func (v *View) GetOrder(orderId string) *Order {
+	located, ok := v.orders.Get(orderId)
+	if !ok {
+		return nil
+	}
+
+	order, _ := v.GetPriceLevel(located.side, located.price).orders.Get(located.rank)
+	return order
+}
+
+//FrontOrders returns up to number orders of the side from the front of the best price level, all orders if number is 0
+func (v *View) FrontOrders(side string, number int) []*Order {
+	var ret []*Order
+	v.RangeLevels(side, func(level *LevelView) bool {
+		level.RangeOrders(func(order *Order) bool {
+			if number > 0 && len(ret) >= number {
+				return false
+			}
+			ret = append(ret, order)
+			return true
+		})
+		return number == 0 || len(ret) < number
+	})
+
+	return ret
+}
+
+//LevelsBetween returns the price levels of the side priced between low and high inclusive, from the best price
+func (v *View) LevelsBetween(side string, low, high int64) []*LevelView {
+	if err := base.CheckSide(side); err != nil || low > high {
+		return nil
+	}
+
+	from := low
+	if side == base.BidSide {
+		from = high
+	}
+
+	var ret []*LevelView
+	v.levels(side).AscendFrom(from, func(price int64, level *LevelView) bool {
+		if side == base.AskSide && price > high || side == base.BidSide && price < low {
+			return false
+		}
+		ret = append(ret, level)
+		return true
+	})
+
+	return ret
+}
+
+//LevelsCovering returns the price levels of the side from the best price until their cumulative size covers size
+func (v *View) LevelsCovering(side string, size int64) []*LevelView {
+	var ret []*LevelView
+	var cumulative int64
+	v.RangeLevels(side, func(level *LevelView) bool {
+		ret = append(ret, level)
+		cumulative += level.Size
+		return cumulative < size
+	})
+
+	return ret
+}
+
+//FormatLevels returns the price and size of the levels
+func (v *View) FormatLevels(levels []*LevelView) [][2]string {
+	arr := make([][2]string, 0, len(levels))
+	for _, level := range levels {
+		arr = append(arr, [2]string{v.FormatPrice(level.Price), v.FormatSize(level.Size)})
+	}
+
+	return arr
+}
+
+//FormatLevelOrders returns the orderId, price and size of the orders of the levels
+func (v *View) FormatLevelOrders(levels []*LevelView) [][3]string {
+	arr := make([][3]string, 0)
+	for _, level := range levels {
+		level.RangeOrders(func(order *Order) bool {
+			arr = append(arr, [3]string{order.OrderId, v.FormatPrice(order.Price), v.FormatSize(order.Size)})
+			return true
+		})
+	}
+
+	return arr
+}
+
+//AggregateLevels returns up to number buckets of the side, all buckets if number is 0,
+//the prices are rounded to multiples of step, up for asks and down for bids,
+//each bucket is [price, size, count of orders]
+func (v *View) AggregateLevels(side string, step int64, number int) [][3]string {
+	arr := make([][3]string, 0)
+	if step <= 0 {
+		return arr
+	}
+
+	var price, size int64
+	count := 0
+	flush := func() {
+		arr = append(arr, [3]string{v.FormatPrice(price), v.FormatSize(size), strconv.Itoa(count)})
+	}
+
+	v.RangeLevels(side, func(level *LevelView) bool {
+		bucket := level.Price / step * step
+		if side == base.AskSide && bucket < level.Price {
+			bucket += step
+		}
+
+		if count > 0 && bucket != price {
+			flush()
+			if number > 0 && len(arr) >= number {
+				count = 0
+				return false
+			}
+			size, count = 0, 0
+		}
+
+		price = bucket
+		size += level.Size
+		count += level.Count
+		return true
+	})
+	if count > 0 {
+		flush()
+	}
+
+	return arr
+}
+
+func (v *View) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"sequence":   v.Sequence,
+		base.AskSide: v.GetL3PartOrderBookBySide(base.AskSide, 0),
+		base.BidSide: v.GetL3PartOrderBookBySide(base.BidSide, 0),
+	})
+}
+
+// Level3 OrderBook
+func (v *View) GetL3PartOrderBookBySide(side string, number int) [][3]string {
+	if err := base.CheckSide(side); err != nil {
+		return nil
+	}
+
+	arr := make([][3]string, 0)
+	v.RangeLevels(side, func(level *LevelView) bool {
+		level.RangeOrders(func(order *Order) bool {
+			if number > 0 && len(arr) >= number {
+				return false
+			}
+
+			arr = append(arr, [3]string{order.OrderId, v.FormatPrice(order.Price), v.FormatSize(order.Size)})
+			return true
+		})
+		return number == 0 || len(arr) < number
+	})
+
+	return arr
+}
+
+// Level2 OrderBook
+func (v *View) GetPartOrderBookBySide(side string, number int) [][2]string {
+	if err := base.CheckSide(side); err != nil {
+		return nil
+	}
+
+	levels := v.levels(side)
+	if number == 0 {
+		number = levels.Len()
+	} else {
+		number = base.Min(number, levels.Len())
+	}
+	arr := make([][2]string, 0, number)
+
+	levels.Ascend(func(price int64, level *LevelView) bool {
+		if len(arr) >= number {
+			return false
+		}
+
+		arr = append(arr, [2]string{v.FormatPrice(level.Price), v.FormatSize(level.Size)})
+		return true
+	})
+
+	return arr
+}
+
+func (v *View) GetOrderBookTickerOrder() (askOrder, bidOrder *Order) {
+	if orders := v.FrontOrders(base.AskSide, 1); len(orders) > 0 {
+		askOrder = orders[0]
+	}
+	if orders := v.FrontOrders(base.BidSide, 1); len(orders) > 0 {
+		bidOrder = orders[0]
+	}
+	return
+}
@@ -32,14 +32,15 @@ const DefaultMaxLevel = 32
 
 // A node is a container for key-value pairs that are stored in a skip
 // list.
-type node struct {
-	forward    []*node
-	backward   *node
-	key, value interface{}
+type node[K, V any] struct {
+	forward  []*node[K, V]
+	backward *node[K, V]
+	key      K
+	value    V
 }
 
 // next returns the next node in the skip list containing n.
-func (n *node) next() *node {
+func (n *node[K, V]) next() *node[K, V] {
 	if len(n.forward) == 0 {
 		return nil
 	}
@@ -47,25 +48,25 @@ func (n *node) next() *node {
 }
 
 // previous returns the previous node in the skip list containing n.
-func (n *node) previous() *node {
+func (n *node[K, V]) previous() *node[K, V] {
 	return n.backward
 }
 
 // hasNext returns true if n has a next node.
-func (n *node) hasNext() bool {
+func (n *node[K, V]) hasNext() bool {
 	return n.next() != nil
 }
 
 // hasPrevious returns true if n has a previous node.
-func (n *node) hasPrevious() bool {
+func (n *node[K, V]) hasPrevious() bool {
 	return n.previous() != nil
 }
 
-func (n *node) Get() interface{} {
+func (n *node[K, V]) Get() V {
 	return n.value
 }
 
-func (n *node) Set(value interface{}) {
+func (n *node[K, V]) Set(value V) {
 	n.value = value
 }
 
@@ -74,17 +75,20 @@ func (n *node) Set(value interface{}) {
 // all O(log n) operations. A SkipList can efficiently store up to
 // 2^MaxLevel items.
 //
+// Keys and values are typed, so the comparison functions work on K
+// directly instead of type-asserting interface{} on every comparison.
+//
 // To iterate over a skip list (where s is a
 // *SkipList):
 //
 //	for i := s.Iterator(); i.Next(); {
 //		// do something with i.Key() and i.Value()
 //	}
-type SkipList struct {
-	lessThan func(l, r interface{}) bool
-	isEqual  func(l, r interface{}) bool
-	header   *node
-	footer   *node
+type SkipList[K, V any] struct {
+	lessThan func(l, r K) bool
+	isEqual  func(l, r K) bool
+	header   *node[K, V]
+	footer   *node[K, V]
 	length   int
 	// MaxLevel determines how many items the SkipList can store
 	// efficiently (2^MaxLevel).
@@ -102,7 +106,7 @@ type SkipList struct {
 }
 
 // Len returns the length of s.
-func (s *SkipList) Len() int {
+func (s *SkipList[K, V]) Len() int {
 	return s.length
 }
 
@@ -111,7 +115,7 @@ func (s *SkipList) Len() int {
 // the documentation of SkipList.
 //
 // Key and Value return the key and the value of the current node.
-type Iterator interface {
+type Iterator[K, V any] interface {
 	// Next returns true if the iterator contains subsequent elements
 	// and advances its state to the next element if that is possible.
 	Next() (ok bool)
@@ -119,36 +123,36 @@ type Iterator interface {
 	// and rewinds its state to the previous element if that is possible.
 	Previous() (ok bool)
 	// Key returns the current key.
-	Key() interface{}
+	Key() K
 	// Value returns the current value.
-	Value() interface{}
+	Value() V
 	// Seek reduces iterative seek costs for searching forward into the Skip List
 	// by remarking the range of keys over which it has scanned before.  If the
 	// requested key occurs prior to the point, the Skip List will start searching
 	// as a safeguard.  It returns true if the key is within the known range of
 	// the list.
-	Seek(key interface{}) (ok bool)
+	Seek(key K) (ok bool)
 	// Close this iterator to reap resources associated with it.  While not
 	// strictly required, it will provide extra hints for the garbage collector.
 	Close()
 }
 
-type iter struct {
-	current *node
-	key     interface{}
-	list    *SkipList
-	value   interface{}
+type iter[K, V any] struct {
+	current *node[K, V]
+	key     K
+	list    *SkipList[K, V]
+	value   V
 }
 
-func (i iter) Key() interface{} {
+func (i iter[K, V]) Key() K {
 	return i.key
 }
 
-func (i iter) Value() interface{} {
+func (i iter[K, V]) Value() V {
 	return i.value
 }
 
-func (i *iter) Next() bool {
+func (i *iter[K, V]) Next() bool {
 	if !i.current.hasNext() {
 		return false
 	}
@@ -160,7 +164,7 @@ func (i *iter) Next() bool {
 	return true
 }
 
-func (i *iter) Previous() bool {
+func (i *iter[K, V]) Previous() bool {
 	if !i.current.hasPrevious() {
 		return false
 	}
@@ -172,7 +176,7 @@ func (i *iter) Previous() bool {
 	return true
 }
 
-func (i *iter) Seek(key interface{}) (ok bool) {
+func (i *iter[K, V]) Seek(key K) (ok bool) {
 	current := i.current
 	list := i.list
 
@@ -185,7 +189,9 @@ func (i *iter) Seek(key interface{}) (ok bool) {
 	// If the target key occurs before the current key, we cannot take advantage
 	// of the heretofore spent traversal cost to find it; resetting back to the
 	// beginning is the safest choice.
-	if current.key != nil && list.lessThan(key, current.key) {
+	// The header, the first node and the head of an empty range have no
+	// previous node and go back to the header below anyway.
+	if current.backward != nil && list.lessThan(key, current.key) {
 		current = list.header
 	}
 
@@ -210,20 +216,22 @@ func (i *iter) Seek(key interface{}) (ok bool) {
 	return true
 }
 
-func (i *iter) Close() {
-	i.key = nil
-	i.value = nil
+func (i *iter[K, V]) Close() {
+	var key K
+	var value V
+	i.key = key
+	i.value = value
 	i.current = nil
 	i.list = nil
 }
 
-type rangeIterator struct {
-	iter
-	upperLimit interface{}
-	lowerLimit interface{}
+type rangeIterator[K, V any] struct {
+	iter[K, V]
+	upperLimit K
+	lowerLimit K
 }
 
-func (i *rangeIterator) Next() bool {
+func (i *rangeIterator[K, V]) Next() bool {
 	if !i.current.hasNext() {
 		return false
 	}
@@ -240,7 +248,7 @@ func (i *rangeIterator) Next() bool {
 	return true
 }
 
-func (i *rangeIterator) Previous() bool {
+func (i *rangeIterator[K, V]) Previous() bool {
 	if !i.current.hasPrevious() {
 		return false
 	}
@@ -257,7 +265,7 @@ func (i *rangeIterator) Previous() bool {
 	return true
 }
 
-func (i *rangeIterator) Seek(key interface{}) (ok bool) {
+func (i *rangeIterator[K, V]) Seek(key K) (ok bool) {
 	if i.list.lessThan(key, i.lowerLimit) {
 		return
 	} else if !i.list.lessThan(key, i.upperLimit) {
@@ -267,15 +275,16 @@ func (i *rangeIterator) Seek(key interface{}) (ok bool) {
 	return i.iter.Seek(key)
 }
 
-func (i *rangeIterator) Close() {
+func (i *rangeIterator[K, V]) Close() {
+	var limit K
 	i.iter.Close()
-	i.upperLimit = nil
-	i.lowerLimit = nil
+	i.upperLimit = limit
+	i.lowerLimit = limit
 }
 
 // Iterator returns an Iterator that will go through all elements s.
-func (s *SkipList) Iterator() Iterator {
-	return &iter{
+func (s *SkipList[K, V]) Iterator() Iterator[K, V] {
+	return &iter[K, V]{
 		current: s.header,
 		list:    s,
 	}
@@ -283,13 +292,13 @@ func (s *SkipList) Iterator() Iterator {
 
 // Seek returns a bidirectional iterator starting with the first element whose
 // key is greater or equal to key; otherwise, a nil iterator is returned.
-func (s *SkipList) Seek(key interface{}) Iterator {
+func (s *SkipList[K, V]) Seek(key K) Iterator[K, V] {
 	current := s.getPath(s.header, nil, key)
 	if current == nil {
 		return nil
 	}
 
-	return &iter{
+	return &iter[K, V]{
 		current: current,
 		key:     current.key,
 		list:    s,
@@ -299,14 +308,14 @@ func (s *SkipList) Seek(key interface{}) Iterator {
 
 // SeekToFirst returns a bidirectional iterator starting from the first element
 // in the list if the list is populated; otherwise, a nil iterator is returned.
-func (s *SkipList) SeekToFirst() Iterator {
+func (s *SkipList[K, V]) SeekToFirst() Iterator[K, V] {
 	if s.length == 0 {
 		return nil
 	}
 
 	current := s.header.next()
 
-	return &iter{
+	return &iter[K, V]{
 		current: current,
 		key:     current.key,
 		list:    s,
@@ -316,13 +325,13 @@ func (s *SkipList) SeekToFirst() Iterator {
 
 // SeekToLast returns a bidirectional iterator starting from the last element
 // in the list if the list is populated; otherwise, a nil iterator is returned.
-func (s *SkipList) SeekToLast() Iterator {
+func (s *SkipList[K, V]) SeekToLast() Iterator[K, V] {
 	current := s.footer
 	if current == nil {
 		return nil
 	}
 
-	return &iter{
+	return &iter[K, V]{
 		current: current,
 		key:     current.key,
 		list:    s,
@@ -333,22 +342,26 @@ func (s *SkipList) SeekToLast() Iterator {
 // Range returns an iterator that will go through all the
 // elements of the skip list that are greater or equal than from, but
 // less than to.
-func (s *SkipList) Range(from, to interface{}) Iterator {
+func (s *SkipList[K, V]) Range(from, to K) Iterator[K, V] {
 	start := s.getPath(s.header, nil, from)
-	return &rangeIterator{
-		iter: iter{
-			current: &node{
-				forward:  []*node{start},
-				backward: start,
-			},
-			list: s,
+	head := &node[K, V]{
+		forward:  []*node[K, V]{start},
+		backward: start,
+	}
+	if start != nil {
+		head.key = start.key
+	}
+	return &rangeIterator[K, V]{
+		iter: iter[K, V]{
+			current: head,
+			list:    s,
 		},
 		upperLimit: to,
 		lowerLimit: from,
 	}
 }
 
-func (s *SkipList) level() int {
+func (s *SkipList[K, V]) level() int {
 	return len(s.header.forward) - 1
 }
 
@@ -359,26 +372,26 @@ func maxInt(x, y int) int {
 	return y
 }
 
-func (s *SkipList) effectiveMaxLevel() int {
+func (s *SkipList[K, V]) effectiveMaxLevel() int {
 	return maxInt(s.level(), s.MaxLevel)
 }
 
 // Returns a new random level.
-func (s SkipList) randomLevel() (n int) {
+func (s *SkipList[K, V]) randomLevel() (n int) {
 	for n = 0; n < s.effectiveMaxLevel() && rand.Float64() < p; n++ {
 	}
 	return
 }
 
-// Get returns the value associated with key from s (nil if the key is
-// not present in s). The second return value is true when the key is
-// present.
-func (s *SkipList) Get(key interface{}) (value interface{}, ok bool) {
+// Get returns the value associated with key from s (the zero value if
+// the key is not present in s). The second return value is true when
+// the key is present.
+func (s *SkipList[K, V]) Get(key K) (value V, ok bool) {
 	candidate := s.getPath(s.header, nil, key)
 
 	// if candidate == nil || candidate.key != key {
 	if candidate == nil || !s.isEqual(candidate.key, key) {
-		return nil, false
+		return value, false
 	}
 
 	return candidate.value, true
@@ -387,7 +400,7 @@ func (s *SkipList) Get(key interface{}) (value interface{}, ok bool) {
 // GetNode returns the node associated with key from s (nil if the key is
 // not present in s). The second return value is true when the key is
 // present.
-func (s *SkipList) GetNode(key interface{}) (value *node, ok bool) {
+func (s *SkipList[K, V]) GetNode(key K) (value *node[K, V], ok bool) {
 	candidate := s.getPath(s.header, nil, key)
 
 	// if candidate == nil || candidate.key != key {
@@ -401,13 +414,13 @@ func (s *SkipList) GetNode(key interface{}) (value *node, ok bool) {
 // GetGreaterOrEqual finds the node whose key is greater than or equal
 // to min. It returns its value, its actual key, and whether such a
 // node is present in the skip list.
-func (s *SkipList) GetGreaterOrEqual(min interface{}) (actualKey, value interface{}, ok bool) {
+func (s *SkipList[K, V]) GetGreaterOrEqual(min K) (actualKey K, value V, ok bool) {
 	candidate := s.getPath(s.header, nil, min)
 
 	if candidate != nil {
 		return candidate.key, candidate.value, true
 	}
-	return nil, nil, false
+	return actualKey, value, false
 }
 
 // getPath populates update with nodes that constitute the path to the
@@ -415,7 +428,7 @@ func (s *SkipList) GetGreaterOrEqual(min interface{}) (actualKey, value interfac
 // update is nil, it will be left alone (the candidate node will still
 // be returned). If update is not nil, but it doesn't have enough
 // slots for all the nodes in the path, getPath will panic.
-func (s *SkipList) getPath(current *node, update []*node, key interface{}) *node {
+func (s *SkipList[K, V]) getPath(current *node[K, V], update []*node[K, V], key K) *node[K, V] {
 	depth := len(current.forward) - 1
 
 	for i := depth; i >= 0; i-- {
@@ -430,12 +443,9 @@ func (s *SkipList) getPath(current *node, update []*node, key interface{}) *node
 }
 
 // Sets set the value associated with key in s.
-func (s *SkipList) Set(key, value interface{}) {
-	if key == nil {
-		panic("goskiplist: nil keys are not supported")
-	}
+func (s *SkipList[K, V]) Set(key K, value V) {
 	// s.level starts from 0, so we need to allocate one.
-	update := make([]*node, s.level()+1, s.effectiveMaxLevel()+1)
+	update := make([]*node[K, V], s.level()+1, s.effectiveMaxLevel()+1)
 	candidate := s.getPath(s.header, update, key)
 
 	// if candidate != nil && candidate.key == key {
@@ -456,13 +466,13 @@ func (s *SkipList) Set(key, value interface{}) {
 		}
 	}
 
-	newNode := &node{
-		forward: make([]*node, newLevel+1, s.effectiveMaxLevel()+1),
+	newNode := &node[K, V]{
+		forward: make([]*node[K, V], newLevel+1, s.effectiveMaxLevel()+1),
 		key:     key,
 		value:   value,
 	}
 
-	if previous := update[0]; previous.key != nil {
+	if previous := update[0]; previous != s.header {
 		newNode.backward = previous
 	}
 
@@ -487,16 +497,13 @@ func (s *SkipList) Set(key, value interface{}) {
 // Delete removes the node with the given key.
 //
 // It returns the old value and whether the node was present.
-func (s *SkipList) Delete(key interface{}) (value interface{}, ok bool) {
-	if key == nil {
-		panic("goskiplist: nil keys are not supported")
-	}
-	update := make([]*node, s.level()+1, s.effectiveMaxLevel())
+func (s *SkipList[K, V]) Delete(key K) (value V, ok bool) {
+	update := make([]*node[K, V], s.level()+1, s.effectiveMaxLevel())
 	candidate := s.getPath(s.header, update, key)
 
 	// if candidate == nil || candidate.key != key {
 	if candidate == nil || !s.isEqual(candidate.key, key) {
-		return nil, false
+		return value, false
 	}
 
 	previous := candidate.backward
@@ -524,12 +531,12 @@ func (s *SkipList) Delete(key interface{}) (value interface{}, ok bool) {
 // NewCustomMap returns a new SkipList that will use lessThan as the
 // comparison function. lessThan should define a linear order on keys
 // you intend to use with the SkipList.
-func NewCustomMap(lessThan func(l, r interface{}) bool, isEqual func(l, r interface{}) bool) *SkipList {
-	return &SkipList{
+func NewCustomMap[K, V any](lessThan func(l, r K) bool, isEqual func(l, r K) bool) *SkipList[K, V] {
+	return &SkipList[K, V]{
 		lessThan: lessThan,
 		isEqual:  isEqual,
-		header: &node{
-			forward: []*node{nil},
+		header: &node[K, V]{
+			forward: []*node[K, V]{nil},
 		},
 		MaxLevel: DefaultMaxLevel,
 	}
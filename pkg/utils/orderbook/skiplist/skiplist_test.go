@@ -21,7 +21,7 @@ import (
 	"testing"
 )
 
-func (s *SkipList) printRepr() {
+func (s *SkipList[K, V]) printRepr() {
 
 	fmt.Printf("header:\n")
 	for i, link := range s.header.forward {
@@ -45,27 +45,21 @@ func (s *SkipList) printRepr() {
 	fmt.Println()
 }
 
-func isEqual(l, r interface{}) bool {
-	switch val := l.(type) {
-	default:
-		if val != r {
-			return false
-		}
-	}
-	return true
+func isEqual[K comparable](l, r K) bool {
+	return l == r
 }
 
 func TestInitialization(t *testing.T) {
-	s := NewCustomMap(func(l, r interface{}) bool {
-		return l.(int) < r.(int)
-	}, isEqual)
+	s := NewCustomMap[int, int](func(l, r int) bool {
+		return l < r
+	}, isEqual[int])
 	if !s.lessThan(1, 2) {
 		t.Errorf("Less than doesn't work correctly.")
 	}
 }
 
 func TestEmptyNodeNext(t *testing.T) {
-	n := new(node)
+	n := new(node[int, int])
 	if next := n.next(); next != nil {
 		t.Errorf("Next() should be nil for an empty node.")
 	}
@@ -76,7 +70,7 @@ func TestEmptyNodeNext(t *testing.T) {
 }
 
 func TestEmptyNodePrev(t *testing.T) {
-	n := new(node)
+	n := new(node[int, int])
 	if previous := n.previous(); previous != nil {
 		t.Errorf("Previous() should be nil for an empty node.")
 	}
@@ -87,10 +81,10 @@ func TestEmptyNodePrev(t *testing.T) {
 }
 
 // NewIntKey returns a SkipList that accepts int keys.
-func newIntMap() *SkipList {
-	return NewCustomMap(func(l, r interface{}) bool {
-		return l.(int) < r.(int)
-	}, isEqual)
+func newIntMap() *SkipList[int, int] {
+	return NewCustomMap[int, int](func(l, r int) bool {
+		return l < r
+	}, isEqual[int])
 }
 
 func TestNodeHasNext(t *testing.T) {
@@ -115,8 +109,8 @@ func TestNodeHasPrev(t *testing.T) {
 	}
 }
 
-func (s *SkipList) check(t *testing.T, key, wanted int) {
-	if got, _ := s.Get(key); got != wanted {
+func (s *SkipList[K, V]) check(t *testing.T, key K, wanted V) {
+	if got, _ := s.Get(key); any(got) != any(wanted) {
 		t.Errorf("For key %v wanted value %v, got %v.", key, wanted, got)
 	}
 }
@@ -129,22 +123,22 @@ func TestGet(t *testing.T) {
 		t.Errorf("%v, %v instead of %v, %v", value, present, 0, true)
 	}
 
-	if value, present := s.Get(100); value != nil || present {
-		t.Errorf("%v, %v instead of %v, %v", value, present, nil, false)
+	if value, present := s.Get(100); value != 0 || present {
+		t.Errorf("%v, %v instead of %v, %v", value, present, 0, false)
 	}
 }
 
 func TestGetGreaterOrEqual(t *testing.T) {
 	s := newIntMap()
 
-	if _, value, present := s.GetGreaterOrEqual(5); !(value == nil && !present) {
-		t.Errorf("s.GetGreaterOrEqual(5) should have returned nil and false for an empty map, not %v and %v.", value, present)
+	if _, value, present := s.GetGreaterOrEqual(5); !(value == 0 && !present) {
+		t.Errorf("s.GetGreaterOrEqual(5) should have returned 0 and false for an empty map, not %v and %v.", value, present)
 	}
 
 	s.Set(0, 0)
 
-	if _, value, present := s.GetGreaterOrEqual(5); !(value == nil && !present) {
-		t.Errorf("s.GetGreaterOrEqual(5) should have returned nil and false for an empty map, not %v and %v.", value, present)
+	if _, value, present := s.GetGreaterOrEqual(5); !(value == 0 && !present) {
+		t.Errorf("s.GetGreaterOrEqual(5) should have returned 0 and false for an empty map, not %v and %v.", value, present)
 	}
 
 	s.Set(10, 10)
@@ -205,8 +199,8 @@ func TestDelete(t *testing.T) {
 		}
 	}
 
-	if v, present := s.Delete(10000); v != nil || present {
-		t.Errorf("Deleting a non-existent key should return nil, false, and not %v, %v.", v, present)
+	if v, present := s.Delete(10000); v != 0 || present {
+		t.Errorf("Deleting a non-existent key should return 0, false, and not %v, %v.", v, present)
 	}
 
 	if t.Failed() {
@@ -253,7 +247,7 @@ func TestIteration(t *testing.T) {
 
 	for i.Next() {
 		seen++
-		lastKey = i.Key().(int)
+		lastKey = i.Key()
 		if i.Key() != i.Value() {
 			t.Errorf("Wrong value for key %v: %v.", i.Key(), i.Value())
 		}
@@ -268,11 +262,11 @@ func TestIteration(t *testing.T) {
 			t.Errorf("Wrong value for key %v: %v.", i.Key(), i.Value())
 		}
 
-		if i.Key().(int) >= lastKey {
+		if i.Key() >= lastKey {
 			t.Errorf("Expected key to descend but ascended from %v to %v.", lastKey, i.Key())
 		}
 
-		lastKey = i.Key().(int)
+		lastKey = i.Key()
 	}
 
 	if lastKey != 0 {
@@ -294,7 +288,7 @@ func TestRangeIteration(t *testing.T) {
 
 	for i.Next() {
 		seen++
-		lastKey = i.Key().(int)
+		lastKey = i.Key()
 		if lastKey > max {
 			max = lastKey
 		}
@@ -324,14 +318,14 @@ func TestRangeIteration(t *testing.T) {
 	if !i.Seek(5) {
 		t.Error("Could not seek to an allowed range.")
 	}
-	if i.Key().(int) != 5 || i.Value().(int) != 5 {
+	if i.Key() != 5 || i.Value() != 5 {
 		t.Errorf("Expected 5 for key and 5 for value, got %d and %d", i.Key(), i.Value())
 	}
 
 	if !i.Seek(7) {
 		t.Error("Could not seek to an allowed range.")
 	}
-	if i.Key().(int) != 7 || i.Value().(int) != 7 {
+	if i.Key() != 7 || i.Value() != 7 {
 		t.Errorf("Expected 7 for key and 7 for value, got %d and %d", i.Key(), i.Value())
 	}
 
@@ -347,7 +341,7 @@ func TestRangeIteration(t *testing.T) {
 
 	for i.Previous() {
 		seen++
-		lastKey = i.Key().(int)
+		lastKey = i.Key()
 		if lastKey > max {
 			max = lastKey
 		}
@@ -383,7 +377,7 @@ func TestSomeMore(t *testing.T) {
 
 }
 
-func makeRandomList(n int) *SkipList {
+func makeRandomList(n int) *SkipList[int, int] {
 	s := newIntMap()
 	for i := 0; i < n; i++ {
 		insert := rand.Int()
@@ -427,17 +421,17 @@ func TestSanity(t *testing.T) {
 	defer i.Close()
 
 	for i.Next() {
-		if last != 0 && i.Key().(int) <= last {
+		if last != 0 && i.Key() <= last {
 			t.Errorf("Not in order!")
 		}
-		last = i.Key().(int)
+		last = i.Key()
 	}
 
 	for i.Previous() {
-		if last != 0 && i.Key().(int) > last {
+		if last != 0 && i.Key() > last {
 			t.Errorf("Not in order!")
 		}
-		last = i.Key().(int)
+		last = i.Key()
 	}
 }
 
@@ -460,7 +454,7 @@ func TestSetMaxLevelInFlight(t *testing.T) {
 
 	for i.Next() {
 		if v, _ := s.Get(i.Key()); v != i.Key() {
-			t.Errorf("Bad values in the skip list (%v). Inserted before the call to s.SetMax(): %t.", v, i.Key().(int)%2 == 0)
+			t.Errorf("Bad values in the skip list (%v). Inserted before the call to s.SetMax(): %t.", v, i.Key()%2 == 0)
 		}
 	}
 }
@@ -511,7 +505,7 @@ func TestIteratorPrevHoles(t *testing.T) {
 		t.Errorf("Expected iterator to move successfully to the next.")
 	}
 
-	if i.Key().(int) != 2 || i.Value().(int) != 2 {
+	if i.Key() != 2 || i.Value() != 2 {
 		t.Errorf("Expected iterator to reach key 2 and value 2, got %v and %v.", i.Key(), i.Value())
 	}
 
@@ -519,7 +513,7 @@ func TestIteratorPrevHoles(t *testing.T) {
 		t.Errorf("Expected iterator to move successfully to the previous.")
 	}
 
-	if i.Key().(int) != 1 || i.Value().(int) != 1 {
+	if i.Key() != 1 || i.Value() != 1 {
 		t.Errorf("Expected iterator to reach key 1 and value 1, got %v and %v.", i.Key(), i.Value())
 	}
 
@@ -533,7 +527,7 @@ func TestIteratorPrevHoles(t *testing.T) {
 		t.Errorf("Expected iterator to move successfully to the previous.")
 	}
 
-	if i.Key().(int) != 0 || i.Value().(int) != 0 {
+	if i.Key() != 0 || i.Value() != 0 {
 		t.Errorf("Expected iterator to reach key 0 and value 0, got %v and %v.", i.Key(), i.Value())
 	}
 }
@@ -564,14 +558,14 @@ func TestIteratorSeek(t *testing.T) {
 	i = m.SeekToFirst()
 	defer i.Close()
 
-	if i.Key().(int) != 0 || i.Value().(int) != 0 {
+	if i.Key() != 0 || i.Value() != 0 {
 		t.Errorf("Expected iterator to reach key 0 and value 0, got %v and %v.", i.Key(), i.Value())
 	}
 
 	i = m.SeekToLast()
 	defer i.Close()
 
-	if i.Key().(int) != 0 || i.Value().(int) != 0 {
+	if i.Key() != 0 || i.Value() != 0 {
 		t.Errorf("Expected iterator to reach key 0 and value 0, got %v and %v.", i.Key(), i.Value())
 	}
 
@@ -580,14 +574,14 @@ func TestIteratorSeek(t *testing.T) {
 	i = m.SeekToFirst()
 	defer i.Close()
 
-	if i.Key().(int) != 0 || i.Value().(int) != 0 {
+	if i.Key() != 0 || i.Value() != 0 {
 		t.Errorf("Expected iterator to reach key 0 and value 0, got %v and %v.", i.Key(), i.Value())
 	}
 
 	i = m.SeekToLast()
 	defer i.Close()
 
-	if i.Key().(int) != 1 || i.Value().(int) != 1 {
+	if i.Key() != 1 || i.Value() != 1 {
 		t.Errorf("Expected iterator to reach key 1 and value 1, got %v and %v.", i.Key(), i.Value())
 	}
 
@@ -596,35 +590,35 @@ func TestIteratorSeek(t *testing.T) {
 	i = m.SeekToFirst()
 	defer i.Close()
 
-	if i.Key().(int) != 0 || i.Value().(int) != 0 {
+	if i.Key() != 0 || i.Value() != 0 {
 		t.Errorf("Expected iterator to reach key 0 and value 0, got %v and %v.", i.Key(), i.Value())
 	}
 
 	i = m.SeekToLast()
 	defer i.Close()
 
-	if i.Key().(int) != 2 || i.Value().(int) != 2 {
+	if i.Key() != 2 || i.Value() != 2 {
 		t.Errorf("Expected iterator to reach key 2 and value 2, got %v and %v.", i.Key(), i.Value())
 	}
 
 	i = m.Seek(0)
 	defer i.Close()
 
-	if i.Key().(int) != 0 || i.Value().(int) != 0 {
+	if i.Key() != 0 || i.Value() != 0 {
 		t.Errorf("Expected iterator to reach key 0 and value 0, got %v and %v.", i.Key(), i.Value())
 	}
 
 	i = m.Seek(2)
 	defer i.Close()
 
-	if i.Key().(int) != 2 || i.Value().(int) != 2 {
+	if i.Key() != 2 || i.Value() != 2 {
 		t.Errorf("Expected iterator to reach key 2 and value 2, got %v and %v.", i.Key(), i.Value())
 	}
 
 	i = m.Seek(1)
 	defer i.Close()
 
-	if i.Key().(int) != 1 || i.Value().(int) != 1 {
+	if i.Key() != 1 || i.Value() != 1 {
 		t.Errorf("Expected iterator to reach key 1 and value 1, got %v and %v.", i.Key(), i.Value())
 	}
 
@@ -639,14 +633,14 @@ func TestIteratorSeek(t *testing.T) {
 	i = m.Seek(4)
 	defer i.Close()
 
-	if i.Key().(int) != 4 || i.Value().(int) != 4 {
+	if i.Key() != 4 || i.Value() != 4 {
 		t.Errorf("Expected iterator to reach key 4 and value 4, got %v and %v.", i.Key(), i.Value())
 	}
 
 	i = m.Seek(3)
 	defer i.Close()
 
-	if i.Key().(int) != 4 || i.Value().(int) != 4 {
+	if i.Key() != 4 || i.Value() != 4 {
 		t.Errorf("Expected iterator to reach key 4 and value 4, got %v and %v.", i.Key(), i.Value())
 	}
 
@@ -655,14 +649,14 @@ func TestIteratorSeek(t *testing.T) {
 	i = m.SeekToFirst()
 	defer i.Close()
 
-	if i.Key().(int) != 0 || i.Value().(int) != 0 {
+	if i.Key() != 0 || i.Value() != 0 {
 		t.Errorf("Expected iterator to reach key 0 and value 0, got %v and %v.", i.Key(), i.Value())
 	}
 
 	i = m.SeekToLast()
 	defer i.Close()
 
-	if i.Key().(int) != 2 || i.Value().(int) != 2 {
+	if i.Key() != 2 || i.Value() != 2 {
 		t.Errorf("Expected iterator to reach key 2 and value 2, got %v and %v.", i.Key(), i.Value())
 	}
 
@@ -670,7 +664,7 @@ func TestIteratorSeek(t *testing.T) {
 		t.Error("Expected iterator to seek to key.")
 	}
 
-	if i.Key().(int) != 2 || i.Value().(int) != 2 {
+	if i.Key() != 2 || i.Value() != 2 {
 		t.Errorf("Expected iterator to reach key 2 and value 2, got %v and %v.", i.Key(), i.Value())
 	}
 
@@ -678,7 +672,7 @@ func TestIteratorSeek(t *testing.T) {
 		t.Error("Expected iterator to seek to key.")
 	}
 
-	if i.Key().(int) != 1 || i.Value().(int) != 1 {
+	if i.Key() != 1 || i.Value() != 1 {
 		t.Errorf("Expected iterator to reach key 1 and value 1, got %v and %v.", i.Key(), i.Value())
 	}
 
@@ -686,7 +680,7 @@ func TestIteratorSeek(t *testing.T) {
 		t.Error("Expected iterator to seek to key.")
 	}
 
-	if i.Key().(int) != 0 || i.Value().(int) != 0 {
+	if i.Key() != 0 || i.Value() != 0 {
 		t.Errorf("Expected iterator to reach key 0 and value 0, got %v and %v.", i.Key(), i.Value())
 	}
 
@@ -697,7 +691,7 @@ func TestIteratorSeek(t *testing.T) {
 		t.Error("Expected iterator to seek to key.")
 	}
 
-	if i.Key().(int) != 0 || i.Value().(int) != 0 {
+	if i.Key() != 0 || i.Value() != 0 {
 		t.Errorf("Expected iterator to reach key 0 and value 0, got %v and %v.", i.Key(), i.Value())
 	}
 }
@@ -771,7 +765,7 @@ func BenchmarkForwardSeek(b *testing.B) {
 			nextKey := values[i+lookAhead]
 
 			iterator = s.Seek(nextKey)
-			if iterator.Key().(int) != nextKey || iterator.Value().(int) != nextKey {
+			if iterator.Key() != nextKey || iterator.Value() != nextKey {
 				b.Errorf("%d. expected %d key and %d value, got %d key and %d value", i, nextKey, nextKey, iterator.Key(), iterator.Value())
 			}
 		}
@@ -802,7 +796,7 @@ func BenchmarkForwardSeekReusedIterator(b *testing.B) {
 
 			if !iterator.Seek(nextKey) {
 				b.Errorf("%d. expected iterator to seek to %d key; failed.", i, nextKey)
-			} else if iterator.Key().(int) != nextKey || iterator.Value().(int) != nextKey {
+			} else if iterator.Key() != nextKey || iterator.Value() != nextKey {
 				b.Errorf("%d. expected %d key and %d value, got %d key and %d value", i, nextKey, nextKey, iterator.Key(), iterator.Value())
 			}
 		}
@@ -810,10 +804,10 @@ func BenchmarkForwardSeekReusedIterator(b *testing.B) {
 }
 
 // newStringMap returns a SkipList that accepts string keys.
-func newStringMap() *SkipList {
-	return NewCustomMap(func(l, r interface{}) bool {
-		return l.(string) < r.(string)
-	}, isEqual)
+func newStringMap() *SkipList[string, int] {
+	return NewCustomMap[string, int](func(l, r string) bool {
+		return l < r
+	}, isEqual[string])
 }
 
 func TestNewStringMap(t *testing.T) {
@@ -825,22 +819,132 @@ func TestNewStringMap(t *testing.T) {
 	}
 }
 
-func TestGetNilKey(t *testing.T) {
+func TestGetZeroKey(t *testing.T) {
 	s := newStringMap()
-	if v, present := s.Get(nil); v != nil || present {
-		t.Errorf("s.Get(nil) should return nil, false (not %v, %v).", v, present)
+	if v, present := s.Get(""); v != 0 || present {
+		t.Errorf("s.Get(\"\") should return 0, false (not %v, %v).", v, present)
 	}
 }
 
-func TestSetNilKey(t *testing.T) {
+func TestSetZeroKey(t *testing.T) {
 	s := newStringMap()
+	s.Set("", 1)
+	s.Set("a", 2)
+
+	if v, present := s.Get(""); v != 1 || !present {
+		t.Errorf("s.Get(\"\") should return 1, true (not %v, %v).", v, present)
+	}
+
+	i := s.Iterator()
+	defer i.Close()
+
+	if !i.Next() || i.Key() != "" {
+		t.Errorf("The zero key should be the first key, not %q.", i.Key())
+	}
+}
 
-	defer func() {
-		if err := recover(); err == nil {
-			t.Errorf("s.Set(nil, 0) should have panicked.")
+// benchOrder is ordered by price, then by time, like the orders of an
+// order book side.
+type benchOrder struct {
+	id    int
+	price int
+	time  int
+}
+
+func makeBenchOrders(n int) []*benchOrder {
+	orders := make([]*benchOrder, n)
+	for i := range orders {
+		orders[i] = &benchOrder{id: i, price: rand.Intn(1000), time: i}
+	}
+	return orders
+}
+
+func newOrderMap() *SkipList[*benchOrder, *benchOrder] {
+	return NewCustomMap[*benchOrder, *benchOrder](func(l, r *benchOrder) bool {
+		if l.price == r.price {
+			return l.time < r.time
 		}
-	}()
+		return l.price < r.price
+	}, func(l, r *benchOrder) bool {
+		return l.id == r.id
+	})
+}
+
+// newInterfaceOrderMap type-asserts the keys on every comparison, as
+// the order books did before the skip list was generic.
+func newInterfaceOrderMap() *SkipList[interface{}, interface{}] {
+	return NewCustomMap[interface{}, interface{}](func(l, r interface{}) bool {
+		if l.(*benchOrder).price == r.(*benchOrder).price {
+			return l.(*benchOrder).time < r.(*benchOrder).time
+		}
+		return l.(*benchOrder).price < r.(*benchOrder).price
+	}, func(l, r interface{}) bool {
+		switch val := l.(type) {
+		case *benchOrder:
+			if val.id != r.(*benchOrder).id {
+				return false
+			}
+		default:
+			if val != r {
+				return false
+			}
+		}
+		return true
+	})
+}
 
-	s.Set(nil, 0)
+func BenchmarkOrderSetDelete(b *testing.B) {
+	orders := makeBenchOrders(65536)
+	s := newOrderMap()
+	for _, order := range orders {
+		s.Set(order, order)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		order := orders[i%len(orders)]
+		s.Delete(order)
+		s.Set(order, order)
+	}
+}
+
+func BenchmarkOrderSetDeleteInterface(b *testing.B) {
+	orders := makeBenchOrders(65536)
+	s := newInterfaceOrderMap()
+	for _, order := range orders {
+		s.Set(order, order)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		order := orders[i%len(orders)]
+		s.Delete(order)
+		s.Set(order, order)
+	}
+}
 
+func BenchmarkOrderIteration(b *testing.B) {
+	s := newOrderMap()
+	for _, order := range makeBenchOrders(1024) {
+		s.Set(order, order)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		size := 0
+		for it := s.Iterator(); it.Next(); {
+			size += it.Value().price
+		}
+	}
+}
+
+func BenchmarkOrderIterationInterface(b *testing.B) {
+	s := newInterfaceOrderMap()
+	for _, order := range makeBenchOrders(1024) {
+		s.Set(order, order)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		size := 0
+		for it := s.Iterator(); it.Next(); {
+			size += it.Value().(*benchOrder).price
+		}
+	}
 }
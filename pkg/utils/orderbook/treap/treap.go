@@ -0,0 +1,186 @@
+//Package treap implements a persistent ordered map, a change returns a new map sharing the unchanged nodes
+//with the old one, which is never changed, so any goroutine reads a map without locking while another derives the next one
+package treap
+
+import (
+	"math/rand"
+)
+
+type node[K, V any] struct {
+	key      K
+	value    V
+	priority uint32 //a node has a priority not lower than its children, so the tree is balanced on average
+	left     *node[K, V]
+	right    *node[K, V]
+}
+
+func (n *node[K, V]) copy() *node[K, V] {
+	c := *n
+	return &c
+}
+
+//Map is an immutable map ordered by lessThan, Set and Delete copy O(log n) nodes on average
+type Map[K, V any] struct {
+	root     *node[K, V]
+	length   int
+	lessThan func(l, r K) bool
+}
+
+//NewCustomMap returns an empty map ordered by lessThan
+func NewCustomMap[K, V any](lessThan func(l, r K) bool) *Map[K, V] {
+	return &Map[K, V]{
+		lessThan: lessThan,
+	}
+}
+
+func (m *Map[K, V]) Len() int {
+	return m.length
+}
+
+func (m *Map[K, V]) Get(key K) (value V, ok bool) {
+	for n := m.root; n != nil; {
+		switch {
+		case m.lessThan(key, n.key):
+			n = n.left
+		case m.lessThan(n.key, key):
+			n = n.right
+		default:
+			return n.value, true
+		}
+	}
+
+	return value, false
+}
+
+//Set returns a map with the value of key, m is not changed
+func (m *Map[K, V]) Set(key K, value V) *Map[K, V] {
+	root, added := m.insert(m.root, key, value, rand.Uint32())
+	ret := &Map[K, V]{root: root, length: m.length, lessThan: m.lessThan}
+	if added {
+		ret.length++
+	}
+
+	return ret
+}
+
+//insert copies the path to key, rotating the new node up to keep the priorities in heap order
+func (m *Map[K, V]) insert(n *node[K, V], key K, value V, priority uint32) (ret *node[K, V], added bool) {
+	if n == nil {
+		return &node[K, V]{key: key, value: value, priority: priority}, true
+	}
+
+	ret = n.copy()
+	switch {
+	case m.lessThan(key, n.key):
+		ret.left, added = m.insert(n.left, key, value, priority)
+		if ret.left.priority > ret.priority {
+			//the copied nodes are not shared yet, so they are rotated in place
+			left := ret.left
+			ret.left, left.right = left.right, ret
+			ret = left
+		}
+	case m.lessThan(n.key, key):
+		ret.right, added = m.insert(n.right, key, value, priority)
+		if ret.right.priority > ret.priority {
+			right := ret.right
+			ret.right, right.left = right.left, ret
+			ret = right
+		}
+	default:
+		ret.value = value
+	}
+
+	return ret, added
+}
+
+//Delete returns a map without key, m itself if there is no key
+func (m *Map[K, V]) Delete(key K) *Map[K, V] {
+	root, deleted := m.delete(m.root, key)
+	if !deleted {
+		return m
+	}
+
+	return &Map[K, V]{root: root, length: m.length - 1, lessThan: m.lessThan}
+}
+
+func (m *Map[K, V]) delete(n *node[K, V], key K) (ret *node[K, V], deleted bool) {
+	if n == nil {
+		return nil, false
+	}
+
+	switch {
+	case m.lessThan(key, n.key):
+		left, deleted := m.delete(n.left, key)
+		if !deleted {
+			return n, false
+		}
+		ret = n.copy()
+		ret.left = left
+		return ret, true
+	case m.lessThan(n.key, key):
+		right, deleted := m.delete(n.right, key)
+		if !deleted {
+			return n, false
+		}
+		ret = n.copy()
+		ret.right = right
+		return ret, true
+	}
+
+	return merge(n.left, n.right), true
+}
+
+//merge joins two trees whose keys of l are all less than the keys of r, copying the nodes along the seam
+func merge[K, V any](l, r *node[K, V]) *node[K, V] {
+	switch {
+	case l == nil:
+		return r
+	case r == nil:
+		return l
+	case l.priority > r.priority:
+		ret := l.copy()
+		ret.right = merge(l.right, r)
+		return ret
+	default:
+		ret := r.copy()
+		ret.left = merge(l, r.left)
+		return ret
+	}
+}
+
+//Ascend calls fn with the keys and values in order until fn returns false
+func (m *Map[K, V]) Ascend(fn func(key K, value V) bool) {
+	m.ascend(m.root, nil, fn)
+}
+
+//AscendFrom calls fn with the keys not less than from and their values in order until fn returns false
+func (m *Map[K, V]) AscendFrom(from K, fn func(key K, value V) bool) {
+	m.ascend(m.root, &from, fn)
+}
+
+//ascend walks the tree in order with a stack, skipping the subtrees less than from
+func (m *Map[K, V]) ascend(n *node[K, V], from *K, fn func(key K, value V) bool) {
+	//deep enough for a balanced tree of any size, allocated with the frame
+	var buf [64]*node[K, V]
+	stack := buf[:0]
+	for n != nil || len(stack) > 0 {
+		for n != nil {
+			if from != nil && m.lessThan(n.key, *from) {
+				n = n.right
+				continue
+			}
+			stack = append(stack, n)
+			n = n.left
+		}
+		if len(stack) == 0 {
+			return
+		}
+
+		n = stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if !fn(n.key, n.value) {
+			return
+		}
+		n = n.right
+	}
+}
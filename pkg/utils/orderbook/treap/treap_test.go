@@ -0,0 +1,102 @@
+package treap
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func keys(m *Map[int, int]) []int {
+	ret := []int{}
+	m.Ascend(func(key int, value int) bool {
+		ret = append(ret, key)
+		return true
+	})
+	return ret
+}
+
+func sortedKeys(ref map[int]int) []int {
+	ret := []int{}
+	for key := range ref {
+		ret = append(ret, key)
+	}
+	sort.Ints(ret)
+	return ret
+}
+
+//TestPersistence checks every map derived by random changes against a builtin map,
+//and the maps kept on the way are not changed by the later changes
+func TestPersistence(t *testing.T) {
+	type version struct {
+		m    *Map[int, int]
+		keys []int
+	}
+
+	rnd := rand.New(rand.NewSource(1))
+	m := NewCustomMap[int, int](func(l, r int) bool { return l < r })
+	ref := map[int]int{}
+	var versions []version
+	for i := 0; i < 5000; i++ {
+		key := rnd.Intn(500)
+		if rnd.Intn(3) == 0 {
+			m = m.Delete(key)
+			delete(ref, key)
+		} else {
+			m = m.Set(key, i)
+			ref[key] = i
+		}
+
+		if m.Len() != len(ref) {
+			t.Fatalf("step %d: len %d, want %d", i, m.Len(), len(ref))
+		}
+		want, wantOk := ref[key]
+		if value, ok := m.Get(key); ok != wantOk || value != want {
+			t.Fatalf("step %d: get %d: %d, %v", i, key, value, ok)
+		}
+		if i%100 == 0 {
+			versions = append(versions, version{m: m, keys: sortedKeys(ref)})
+		}
+	}
+
+	if !reflect.DeepEqual(keys(m), sortedKeys(ref)) {
+		t.Errorf("unexpected keys %v", keys(m))
+	}
+	for i, v := range versions {
+		if got := keys(v.m); !reflect.DeepEqual(got, v.keys) || v.m.Len() != len(v.keys) {
+			t.Fatalf("version %d is changed: %v", i, got)
+		}
+	}
+}
+
+func TestAscendFrom(t *testing.T) {
+	//descending, like the bids
+	m := NewCustomMap[int, string](func(l, r int) bool { return l > r })
+	for _, key := range []int{5, 1, 9, 3, 7} {
+		m = m.Set(key, "")
+	}
+
+	tests := []struct {
+		from int
+		want []int
+	}{
+		{10, []int{9, 7, 5}},
+		{7, []int{7, 5, 3}},
+		{2, []int{1}},
+		{0, nil},
+	}
+	for _, test := range tests {
+		var got []int
+		m.AscendFrom(test.from, func(key int, value string) bool {
+			got = append(got, key)
+			return len(got) < 3
+		})
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("from %d: got %v, want %v", test.from, got, test.want)
+		}
+	}
+
+	if m.Delete(4) != m {
+		t.Errorf("deleting a missing key should return the map itself")
+	}
+}